@@ -0,0 +1,251 @@
+// Package auth authenticates API clients by bearer token and limits each token to the
+// RPCs and device hostnames it is scoped to. Every rejected request is written to an
+// audit log.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"vhs/pkg/vhs/server"
+
+	"github.com/twitchtv/twirp"
+)
+
+// Any allows every RPC or hostname.
+const Any = "*"
+
+// RPCs lists the methods of the VHS service tokens can be scoped to.
+var RPCs = []string{
+	"Backup",
+	"ListDevices",
+	"GetLatestBackup",
+	"GetBackupAt",
+	"GetDeviceHistory",
+	"DiffBackup",
+	"GetBackupStatus",
+	"GetSyncStatus",
+}
+
+// Token is an API token. Only the SHA-256 of the token is configured, so the
+// configuration does not hold anything a collector could authenticate with.
+type Token struct {
+	// Name identifies the collector using the token in the audit log.
+	Name string `yaml:"name"`
+	// SHA256 is the hex encoded SHA-256 of the token, as printed by
+	// `printf %s "$TOKEN" | sha256sum`.
+	SHA256 string `yaml:"sha256"`
+	// Hosts are path.Match patterns of the hostnames the token may back up and read, or
+	// Any.
+	Hosts []string `yaml:"hosts"`
+	// RPCs are the methods the token may call, or Any.
+	RPCs []string `yaml:"rpcs"`
+}
+
+// Identity is the authenticated client of a request.
+type Identity struct {
+	Name  string
+	hosts []string
+	rpcs  map[string]bool
+	// remote is the address the request came from, for the audit log.
+	remote string
+}
+
+// AllowsRPC reports whether the identity may call method.
+func (i Identity) AllowsRPC(method string) bool {
+	return i.rpcs[Any] || i.rpcs[method]
+}
+
+// AllowsHost reports whether the identity may back up or read the device named host.
+func (i Identity) AllowsHost(host string) bool {
+	for _, pattern := range i.hosts {
+		if pattern == Any {
+			return true
+		}
+		if ok, _ := path.Match(pattern, host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+type identityKey struct{}
+
+// FromContext returns the identity of the client that made a request.
+func FromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
+
+// NewContext returns a copy of ctx carrying identity.
+func NewContext(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// Authenticator checks the bearer tokens of API requests.
+type Authenticator struct {
+	tokens map[[sha256.Size]byte]Identity
+	audit  *log.Logger
+}
+
+// NewAuthenticator builds an Authenticator accepting tokens. Rejections are logged to audit.
+func NewAuthenticator(tokens []Token, audit *log.Logger) (*Authenticator, error) {
+	a := &Authenticator{tokens: make(map[[sha256.Size]byte]Identity), audit: audit}
+	names := make(map[string]bool)
+	var errs []error
+	for i, t := range tokens {
+		if err := validateToken(t); err != nil {
+			errs = append(errs, fmt.Errorf("token %d: %w", i+1, err))
+			continue
+		}
+		if names[t.Name] {
+			errs = append(errs, fmt.Errorf("token %d: name %q is used more than once", i+1, t.Name))
+			continue
+		}
+		names[t.Name] = true
+		var sum [sha256.Size]byte
+		hex.Decode(sum[:], []byte(t.SHA256))
+		if _, ok := a.tokens[sum]; ok {
+			errs = append(errs, fmt.Errorf("token %d: sha256 is used more than once", i+1))
+			continue
+		}
+		identity := Identity{Name: t.Name, hosts: t.Hosts, rpcs: make(map[string]bool)}
+		for _, rpc := range t.RPCs {
+			identity.rpcs[rpc] = true
+		}
+		a.tokens[sum] = identity
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func validateToken(t Token) error {
+	if t.Name == "" {
+		return errors.New("name must be set")
+	}
+	if sum, err := hex.DecodeString(t.SHA256); err != nil || len(sum) != sha256.Size {
+		return errors.New("sha256 must be 64 hex digits")
+	}
+	if len(t.Hosts) == 0 {
+		return fmt.Errorf("hosts must list at least one pattern, or %q", Any)
+	}
+	for _, pattern := range t.Hosts {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid hosts pattern %q", pattern)
+		}
+	}
+	if len(t.RPCs) == 0 {
+		return fmt.Errorf("rpcs must list at least one method, or %q", Any)
+	}
+	for _, rpc := range t.RPCs {
+		if rpc != Any && !isRPC(rpc) {
+			return fmt.Errorf("unknown rpc %q, must be one of %s", rpc, strings.Join(RPCs, ", "))
+		}
+	}
+	return nil
+}
+
+func isRPC(name string) bool {
+	for _, rpc := range RPCs {
+		if rpc == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Wrap authenticates every request to next by its bearer token. Requests without a
+// known token are rejected with a Twirp unauthenticated error.
+func (a *Authenticator) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, err := a.authenticate(r)
+		if err != nil {
+			a.audit.Printf("rejected remote=%s rpc=%s: %v", r.RemoteAddr, path.Base(r.URL.Path), err)
+			twirp.WriteError(w, twirp.NewError(twirp.Unauthenticated, err.Error()))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), identity)))
+	})
+}
+
+func (a *Authenticator) authenticate(r *http.Request) (Identity, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return Identity{}, errors.New("missing bearer token")
+	}
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return Identity{}, errors.New("authorization must be a bearer token")
+	}
+	// Tokens are looked up by their hash, so lookup timing tells nothing about them.
+	identity, ok := a.tokens[sha256.Sum256([]byte(token))]
+	if !ok {
+		return Identity{}, errors.New("unknown bearer token")
+	}
+	identity.remote = r.RemoteAddr
+	return identity, nil
+}
+
+// Interceptor rejects calls the authenticated identity is not scoped to with a Twirp
+// permission denied error, and drops the devices it may not see from device listings.
+func (a *Authenticator) Interceptor() twirp.Interceptor {
+	return func(next twirp.Method) twirp.Method {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			method, _ := twirp.MethodName(ctx)
+			identity, ok := FromContext(ctx)
+			if !ok {
+				return nil, a.deny(Identity{}, method, "", "request was not authenticated")
+			}
+			if !identity.AllowsRPC(method) {
+				return nil, a.deny(identity, method, "", "rpc is not allowed for this token")
+			}
+			if host, ok := requestHost(request); ok && !identity.AllowsHost(host) {
+				return nil, a.deny(identity, method, host, "host is not allowed for this token")
+			}
+			response, err := next(ctx, request)
+			if err != nil {
+				return response, err
+			}
+			switch r := response.(type) {
+			case *server.ListDevicesResponse:
+				allowed := r.Devices[:0]
+				for _, device := range r.Devices {
+					if identity.AllowsHost(device.GetHost()) {
+						allowed = append(allowed, device)
+					}
+				}
+				r.Devices = allowed
+			case *server.GetBackupStatusResponse:
+				if !identity.AllowsHost(r.GetHost()) {
+					return nil, a.deny(identity, method, r.GetHost(), "host is not allowed for this token")
+				}
+			}
+			return response, nil
+		}
+	}
+}
+
+func (a *Authenticator) deny(identity Identity, method string, host string, reason string) error {
+	a.audit.Printf("denied remote=%s token=%s rpc=%s host=%s: %s", identity.remote, identity.Name, method, host, reason)
+	return twirp.NewError(twirp.PermissionDenied, reason)
+}
+
+// requestHost returns the device hostname a request is about, if any, trimmed like the
+// server trims it.
+func requestHost(request interface{}) (string, bool) {
+	switch r := request.(type) {
+	case *server.BackupRequest:
+		return strings.TrimSpace(r.GetDevice().GetHost()), true
+	case interface{ GetHost() string }:
+		return strings.TrimSpace(r.GetHost()), true
+	}
+	return "", false
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestScopes(t *testing.T) {
+	a, err := NewAuthenticator([]Token{
+		{Name: "collector-ams", SHA256: hash("ams"), Hosts: []string{"ams-*", "core-01"}, RPCs: []string{"Backup", "GetBackupStatus"}},
+		{Name: "reader", SHA256: hash("reader"), Hosts: []string{Any}, RPCs: []string{Any}},
+	}, nil)
+	require.NoError(t, err)
+
+	collector := a.tokens[sha256.Sum256([]byte("ams"))]
+	assert.Equal(t, "collector-ams", collector.Name)
+	assert.True(t, collector.AllowsRPC("Backup"))
+	assert.False(t, collector.AllowsRPC("GetLatestBackup"))
+	assert.True(t, collector.AllowsHost("ams-sw-01"))
+	assert.True(t, collector.AllowsHost("core-01"))
+	assert.False(t, collector.AllowsHost("core-02"))
+	assert.False(t, collector.AllowsHost("fra-sw-01"))
+
+	reader := a.tokens[sha256.Sum256([]byte("reader"))]
+	assert.True(t, reader.AllowsRPC("DiffBackup"))
+	assert.True(t, reader.AllowsHost("fra-sw-01"))
+}
+
+func TestNewAuthenticatorErrors(t *testing.T) {
+	_, err := NewAuthenticator([]Token{
+		{SHA256: hash("a"), Hosts: []string{Any}, RPCs: []string{Any}},
+		{Name: "short", SHA256: "abc", Hosts: []string{Any}, RPCs: []string{Any}},
+		{Name: "no-hosts", SHA256: hash("b"), RPCs: []string{Any}},
+		{Name: "bad-hosts", SHA256: hash("c"), Hosts: []string{"["}, RPCs: []string{Any}},
+		{Name: "no-rpcs", SHA256: hash("d"), Hosts: []string{Any}},
+		{Name: "bad-rpcs", SHA256: hash("e"), Hosts: []string{Any}, RPCs: []string{"Restore"}},
+		{Name: "ok", SHA256: hash("f"), Hosts: []string{Any}, RPCs: []string{Any}},
+		{Name: "ok", SHA256: hash("g"), Hosts: []string{Any}, RPCs: []string{Any}},
+		{Name: "same-token", SHA256: hash("f"), Hosts: []string{Any}, RPCs: []string{Any}},
+	}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "token 1: name must be set")
+	assert.Contains(t, err.Error(), "token 2: sha256 must be 64 hex digits")
+	assert.Contains(t, err.Error(), `token 3: hosts must list at least one pattern, or "*"`)
+	assert.Contains(t, err.Error(), `token 4: invalid hosts pattern "["`)
+	assert.Contains(t, err.Error(), `token 5: rpcs must list at least one method, or "*"`)
+	assert.Contains(t, err.Error(), `token 6: unknown rpc "Restore"`)
+	assert.Contains(t, err.Error(), `token 8: name "ok" is used more than once`)
+	assert.Contains(t, err.Error(), "token 9: sha256 is used more than once")
+}
@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+)
+
+type collectorKey struct{}
+
+// CollectorFromContext returns the collector identified by the verified client
+// certificate of a request.
+func CollectorFromContext(ctx context.Context) (string, bool) {
+	collector, ok := ctx.Value(collectorKey{}).(string)
+	return collector, ok
+}
+
+// WithClientCertificates records the collector identified by the verified client
+// certificate of every request to next, see CertificateIdentity. Requests without a
+// verified certificate are passed on as they are.
+func WithClientCertificates(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+			if name := CertificateIdentity(r.TLS.VerifiedChains[0][0]); name != "" {
+				r = r.WithContext(context.WithValue(r.Context(), collectorKey{}, name))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CertificateIdentity returns the collector a client certificate identifies: its first
+// DNS subject alternative name, or else its common name.
+func CertificateIdentity(cert *x509.Certificate) string {
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0]
+	}
+	return cert.Subject.CommonName
+}
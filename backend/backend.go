@@ -0,0 +1,69 @@
+// Package backend opens the storage backend selected by the configuration.
+package backend
+
+import (
+	"fmt"
+	"vhs/config"
+	"vhs/git"
+	"vhs/gogit"
+	"vhs/storage"
+)
+
+// Open opens the configured storage backend. The git backends are cloned from their
+// remote and brought up to date first.
+func Open(cfg *config.Config) (storage.Store, error) {
+	layout, err := Layout(cfg)
+	if err != nil {
+		return nil, err
+	}
+	switch cfg.Storage.Backend {
+	case config.BackendFilesystem:
+		return storage.NewFileStore(cfg.Repository.Dir, layout)
+	case config.BackendGoGit:
+		return gogit.Open(cfg.Repository.Dir, gogit.Options{
+			URL:         cfg.Repository.URL,
+			Branch:      cfg.Repository.Branch,
+			SSHKeyFile:  cfg.Repository.SSHKeyFile,
+			AuthorName:  cfg.Repository.AuthorName,
+			AuthorEmail: cfg.Repository.AuthorEmail,
+			Layout:      layout,
+		})
+	default:
+		g := git.NewGit(cfg.Repository.Dir, cfg.Repository.Branch)
+		g.Layout = layout
+		if err := g.Clone(cfg.Repository.URL); err != nil {
+			return nil, fmt.Errorf("failed to clone repository: %w", err)
+		}
+		// Set up the upstream branch for the local branch.
+		if err := g.SetUpstreamBranch(); err != nil {
+			return nil, err
+		}
+		// Pull the latest changes before starting the application.
+		if err := g.Pull(); err != nil {
+			return nil, fmt.Errorf("failed to pull changes: %w", err)
+		}
+		return &g, nil
+	}
+}
+
+// Encrypted wraps store in a storage.EncryptedStore when encryption is configured, and
+// returns it unchanged otherwise.
+func Encrypted(cfg *config.Config, store storage.Store) (storage.Store, error) {
+	cipher, err := cfg.Encryption.Cipher()
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption keys: %w", err)
+	}
+	if cipher == nil {
+		return store, nil
+	}
+	return storage.NewEncryptedStore(store, cipher), nil
+}
+
+// Layout builds the repository layout described by the configuration.
+func Layout(cfg *config.Config) (*storage.Layout, error) {
+	layout, err := storage.NewLayout(cfg.Layout.Template, cfg.Layout.InventoryFile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid layout: %w", err)
+	}
+	return layout, nil
+}
@@ -7,10 +7,14 @@ import (
 	"golang.org/x/crypto/ssh"
 	"log"
 	"net/http"
+	"os"
 	"regexp"
 	"strings"
 	"time"
 	"vhs/pkg/vhs/server"
+	"vhs/redact"
+
+	"github.com/twitchtv/twirp"
 )
 
 func main() {
@@ -20,12 +24,16 @@ func main() {
 	username := "grpc"
 	password := "53cret"
 
-	// List of commands to execute
-	commands := []string{
+	// Commands preparing the session, their output is not backed up
+	setupCommands := []string{
 		"term len 0",
+	}
+	// The running configuration is the backup's payload; the output of every other
+	// command is stored as its own artifact, so each is versioned on its own.
+	configCommand := "show run"
+	artifactCommands := []string{
 		"show interfaces",
 		"show version",
-		"show run",
 	}
 
 	// SSH client configuration
@@ -51,24 +59,63 @@ func main() {
 	}
 	defer session.Close()
 
-	// Run commands and print output
-	var combinedOutput string
-	for _, cmd := range commands {
+	// The server redacts every backup. Redacting here as well keeps secrets off the network,
+	// but replaces them by REDACTED before the server sees them, so a server in fingerprint
+	// mode cannot tell when a secret was rotated. Set VHS_CLIENT_REDACTION=1 to do it anyway.
+	redactOutput := func(vendor string, output []byte) []byte { return output }
+	if os.Getenv("VHS_CLIENT_REDACTION") == "1" {
+		redactor, err := redact.NewRedactor(redact.DefaultRules())
+		if err != nil {
+			log.Fatalf("Failed to load redaction rules: %s", err)
+		}
+		redactOutput = redactor.Redact
+	}
+	const vendor = "cisco"
+
+	// Run commands and collect their output
+	for _, cmd := range setupCommands {
+		if _, err := runCommand(session, cmd); err != nil {
+			log.Fatalf("Failed to run command: %s", err)
+		}
+	}
+	runningConfig, err := runCommand(session, configCommand)
+	if err != nil {
+		log.Fatalf("Failed to run command: %s", err)
+	}
+	var artifacts []*server.Artifact
+	for _, cmd := range artifactCommands {
 		output, err := runCommand(session, cmd)
 		if err != nil {
 			log.Fatalf("Failed to run command: %s", err)
 		}
-		output = redactSensitiveData(output) // Redact sensitive data
-		combinedOutput += output + "\n"
+		artifacts = append(artifacts, &server.Artifact{
+			Name:    cmd,
+			Payload: redactOutput(vendor, []byte(output+"\n")),
+		})
+	}
+	// The server requires a bearer token once it has tokens configured.
+	ctx := context.Background()
+	if token := os.Getenv("VHS_TOKEN"); token != "" {
+		header := make(http.Header)
+		header.Set("Authorization", "Bearer "+token)
+		if ctx, err = twirp.WithHTTPRequestHeaders(ctx, header); err != nil {
+			log.Fatalf("Failed to set the token: %s", err)
+		}
 	}
-	backup, err := cl.Backup(context.Background(), &server.BackupRequest{Device: &server.Device{
-		Host:    "br01.jared01",
-		Payload: []byte(combinedOutput),
+	backup, err := cl.Backup(ctx, &server.BackupRequest{Device: &server.Device{
+		Host:      "br01.jared01",
+		Payload:   redactOutput(vendor, []byte(runningConfig+"\n")),
+		Artifacts: artifacts,
+		Metadata: &server.DeviceMetadata{
+			Vendor:      vendor,
+			CollectedAt: time.Now().Format(time.RFC3339),
+			Collector:   "vhs-client",
+		},
 	}})
 	if err != nil {
 		fmt.Println(err)
 	}
-	fmt.Println(backup.Success, "with", backup.Status)
+	fmt.Println(backup.Success, "with", backup.Status, "job", backup.JobId)
 }
 
 func runCommand(session *expect.GExpect, cmd string) (string, error) {
@@ -109,27 +156,5 @@ func runCommand(session *expect.GExpect, cmd string) (string, error) {
 	}
 	output = strings.Join(filteredLines, "\n")
 
-	return fmt.Sprintf("++++++++++++++++++++++++++++++++++++++++++++++\n%s\n++++++++++++++++++++++++++++++++++++++++++++++\n%s", cmd, output), nil
-}
-
-func redactSensitiveData(output string) string {
-	redactionPatterns := []string{
-		`(?i)(password|secret)(\s+\d+)?\s+\S+`, // matches "password <password>", "secret <secret>", and case-insensitive variations
-		// Add more regular expressions to redact other sensitive data
-	}
-
-	for _, pattern := range redactionPatterns {
-		re := regexp.MustCompile(pattern)
-		output = re.ReplaceAllStringFunc(output, func(match string) string {
-			parts := strings.SplitN(match, " ", 3)
-			if len(parts) == 3 {
-				return parts[0] + " " + parts[1] + " REDACTED"
-			} else if len(parts) == 2 {
-				return parts[0] + " REDACTED"
-			}
-			return "REDACTED"
-		})
-	}
-
-	return output
+	return output, nil
 }
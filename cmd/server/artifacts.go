@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"vhs/devices"
+	"vhs/pkg/vhs/server"
+	"vhs/storage"
+
+	"github.com/twitchtv/twirp"
+)
+
+// parseArtifacts converts the artifacts sent with a backup, naming each after the file it
+// is stored in.
+func parseArtifacts(artifacts []*server.Artifact) ([]devices.Artifact, error) {
+	var parsed []devices.Artifact
+	for _, artifact := range artifacts {
+		parsed = append(parsed, devices.Artifact{
+			Name:    devices.ArtifactFileName(artifact.GetName()),
+			Payload: artifact.GetPayload(),
+		})
+	}
+	if err := devices.ValidateArtifacts(parsed); err != nil {
+		return nil, twirp.InvalidArgumentError("device.artifacts", err.Error())
+	}
+	return parsed, nil
+}
+
+// backupName returns the name the store reads a device's configuration by, or one of its
+// artifacts when artifact is set. Artifacts may be named by their command as well as by
+// their file name.
+func backupName(host string, artifact string) (string, error) {
+	name, err := deviceName("host", host)
+	if err != nil || artifact == "" {
+		return name, err
+	}
+	artifact = devices.ArtifactFileName(artifact)
+	err = devices.ValidateName(artifact)
+	var invalid *devices.InvalidNameError
+	if errors.As(err, &invalid) {
+		return "", twirp.InvalidArgumentError("artifact", invalid.Reason)
+	}
+	return storage.ArtifactName(name, artifact), err
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"vhs/auth"
+	"vhs/config"
+	"vhs/pkg/vhs/server"
+
+	"github.com/twitchtv/twirp"
+)
+
+// apiHandler returns the Twirp handler serving v and the path prefix to mount it at.
+// With tokens configured, requests must carry one of them and stay within its scopes.
+// Verified client certificates identify the collector of a backup.
+func apiHandler(v *VhsServer, cfg config.AuthConfig) (http.Handler, string, error) {
+	if len(cfg.Tokens) == 0 {
+		log.Println("No API tokens are configured, the API accepts every request")
+		twirpHandler := server.NewVhsServiceServer(v, twirp.WithServerInterceptors(countBackups))
+		return auth.WithClientCertificates(twirpHandler), twirpHandler.PathPrefix(), nil
+	}
+	var out io.Writer = os.Stderr
+	if cfg.AuditLogFile != "" {
+		f, err := os.OpenFile(cfg.AuditLogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, "", err
+		}
+		out = f
+	}
+	authenticator, err := auth.NewAuthenticator(cfg.Tokens, log.New(out, "audit: ", log.LstdFlags))
+	if err != nil {
+		return nil, "", err
+	}
+	twirpHandler := server.NewVhsServiceServer(v, twirp.WithServerInterceptors(countBackups, authenticator.Interceptor()))
+	return auth.WithClientCertificates(authenticator.Wrap(twirpHandler)), twirpHandler.PathPrefix(), nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"vhs/auth"
+	"vhs/config"
+	"vhs/pkg/vhs/server"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twitchtv/twirp"
+)
+
+func withToken(t *testing.T, token string) context.Context {
+	t.Helper()
+	header := make(http.Header)
+	header.Set("Authorization", "Bearer "+token)
+	ctx, err := twirp.WithHTTPRequestHeaders(context.Background(), header)
+	require.NoError(t, err)
+	return ctx
+}
+
+func TestAPIAuthentication(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "vhs-audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	auditLog := filepath.Join(tempDir, "audit.log")
+
+	sum := func(token string) string {
+		s := sha256.Sum256([]byte(token))
+		return hex.EncodeToString(s[:])
+	}
+	handler, prefix, err := apiHandler(newTestServer(t), config.AuthConfig{
+		Tokens: []auth.Token{
+			{Name: "collector-ams", SHA256: sum("ams-token"), Hosts: []string{"ams-*"}, RPCs: []string{"Backup", "GetBackupStatus"}},
+			{Name: "reader", SHA256: sum("reader-token"), Hosts: []string{"ams-sw-02"}, RPCs: []string{"ListDevices", "GetLatestBackup"}},
+		},
+		AuditLogFile: auditLog,
+	})
+	require.NoError(t, err)
+	mux := http.NewServeMux()
+	mux.Handle(prefix, handler)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	client := server.NewVhsServiceProtobufClient(ts.URL, ts.Client())
+
+	backupRequest := func(host string) *server.BackupRequest {
+		return &server.BackupRequest{
+			Device: &server.Device{Host: host, Payload: []byte("hostname " + host + "\n")},
+			Mode:   server.BackupMode_BACKUP_MODE_SYNC,
+		}
+	}
+	_, err = client.Backup(context.Background(), backupRequest("ams-sw-01"))
+	assertTwirpCode(t, twirp.Unauthenticated, err)
+	_, err = client.Backup(withToken(t, "guessed"), backupRequest("ams-sw-01"))
+	assertTwirpCode(t, twirp.Unauthenticated, err)
+
+	ams := withToken(t, "ams-token")
+	response, err := client.Backup(ams, backupRequest("ams-sw-01"))
+	require.NoError(t, err)
+	_, err = client.Backup(ams, backupRequest("ams-sw-02"))
+	require.NoError(t, err)
+	_, err = client.Backup(ams, backupRequest("fra-sw-01"))
+	assertTwirpCode(t, twirp.PermissionDenied, err)
+	_, err = client.GetBackupStatus(ams, &server.GetBackupStatusRequest{JobId: response.JobId})
+	require.NoError(t, err)
+	_, err = client.GetLatestBackup(ams, &server.GetLatestBackupRequest{Host: "ams-sw-01"})
+	assertTwirpCode(t, twirp.PermissionDenied, err)
+
+	reader := withToken(t, "reader-token")
+	_, err = client.GetBackupStatus(reader, &server.GetBackupStatusRequest{JobId: response.JobId})
+	assertTwirpCode(t, twirp.PermissionDenied, err)
+	list, err := client.ListDevices(reader, &server.ListDevicesRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.Devices, 1, "devices outside the token's hosts are not listed")
+	assert.Equal(t, "ams-sw-02", list.Devices[0].Host)
+	_, err = client.GetLatestBackup(reader, &server.GetLatestBackupRequest{Host: "ams-sw-01"})
+	assertTwirpCode(t, twirp.PermissionDenied, err)
+
+	content, err := ioutil.ReadFile(auditLog)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "rejected remote=127.0.0.1:")
+	assert.Contains(t, string(content), "rpc=Backup: missing bearer token")
+	assert.Contains(t, string(content), "token=collector-ams rpc=Backup host=fra-sw-01: host is not allowed for this token")
+	assert.Contains(t, string(content), "token=collector-ams rpc=GetLatestBackup host=: rpc is not allowed for this token")
+	assert.NotContains(t, string(content), "ams-token", "tokens are not logged")
+}
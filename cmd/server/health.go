@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"vhs/config"
+	"vhs/spool"
+
+	"github.com/twitchtv/twirp"
+)
+
+// health answers the liveness and readiness checks of load balancers and orchestrators.
+type health struct {
+	cfg   config.HealthConfig
+	spool *spool.Spool
+	syncs *syncTracker
+
+	mu sync.Mutex
+	// starting says what the server is busy with before it can take backups, and is empty
+	// once it started.
+	starting string
+	stopping bool
+}
+
+func newHealth(cfg config.HealthConfig, sp *spool.Spool, syncs *syncTracker) *health {
+	return &health{cfg: cfg, spool: sp, syncs: syncs, starting: "starting"}
+}
+
+// setStarting records what the server is busy with while it starts. Empty marks it started.
+func (h *health) setStarting(what string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.starting = what
+}
+
+// setStopping records that the server is shutting down and takes no more backups.
+func (h *health) setStopping() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stopping = true
+}
+
+// problems returns the reasons the server should not get backups, if any.
+func (h *health) problems() []string {
+	var problems []string
+	h.mu.Lock()
+	starting, stopping := h.starting, h.stopping
+	h.mu.Unlock()
+	if starting != "" {
+		problems = append(problems, "not started: "+starting)
+	}
+	if stopping {
+		problems = append(problems, "shutting down")
+	}
+	if depth := h.spool.Len(); h.cfg.MaxQueueDepth > 0 && depth >= h.cfg.MaxQueueDepth {
+		problems = append(problems, fmt.Sprintf("ingest queue is saturated: %d backups are waiting to be committed", depth))
+	}
+	if status := h.syncs.Status(); h.cfg.MaxPushFailures > 0 && status.Failures >= h.cfg.MaxPushFailures {
+		problems = append(problems, fmt.Sprintf("the last %d pushes failed: %s", status.Failures, status.LastError))
+	}
+	return problems
+}
+
+// liveness serves /healthz, which succeeds as long as the server answers.
+func (h *health) liveness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, "ok")
+}
+
+// readiness serves /readyz, which fails with the reasons, one per line, while the server
+// should not get backups.
+func (h *health) readiness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	problems := h.problems()
+	if len(problems) == 0 {
+		fmt.Fprintln(w, "ok")
+		return
+	}
+	w.WriteHeader(http.StatusServiceUnavailable)
+	for _, problem := range problems {
+		fmt.Fprintln(w, problem)
+	}
+}
+
+// pendingHandler answers with a Twirp unavailable error until its handler is set, so the
+// API can be mounted before the repository is opened.
+type pendingHandler struct {
+	mu      sync.RWMutex
+	handler http.Handler
+}
+
+func (p *pendingHandler) set(handler http.Handler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handler = handler
+}
+
+func (p *pendingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.RLock()
+	handler := p.handler
+	p.mu.RUnlock()
+	if handler == nil {
+		twirp.WriteError(w, twirp.NewError(twirp.Unavailable, "server is starting"))
+		return
+	}
+	handler.ServeHTTP(w, r)
+}
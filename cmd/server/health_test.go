@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+	"vhs/config"
+	"vhs/devices"
+	"vhs/pkg/vhs/server"
+	"vhs/spool"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twitchtv/twirp"
+)
+
+func check(t *testing.T, handler http.HandlerFunc) (int, string) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest("GET", "/", nil))
+	return recorder.Code, recorder.Body.String()
+}
+
+func TestReadiness(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "vhs-spool")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	// Nothing commits from this spool, so whatever is enqueued stays queued.
+	sp, err := spool.Open(tempDir, 1)
+	require.NoError(t, err)
+	syncs := newSyncTracker()
+	h := newHealth(config.HealthConfig{MaxQueueDepth: 2, MaxPushFailures: 2}, sp, syncs)
+
+	h.setStarting("opening the repository")
+	code, body := check(t, h.readiness)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not started: opening the repository\n", body)
+	code, _ = check(t, h.liveness)
+	assert.Equal(t, http.StatusOK, code, "a starting server is alive")
+
+	h.setStarting("")
+	code, body = check(t, h.readiness)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok\n", body)
+
+	// A single failed push does not take the server out of rotation.
+	syncs.record(time.Now(), errors.New("connection refused"), time.Minute)
+	code, _ = check(t, h.readiness)
+	assert.Equal(t, http.StatusOK, code)
+	syncs.record(time.Now(), errors.New("connection refused"), time.Minute)
+	code, body = check(t, h.readiness)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "the last 2 pushes failed: connection refused\n", body)
+	syncs.record(time.Now(), nil, time.Minute)
+	code, _ = check(t, h.readiness)
+	assert.Equal(t, http.StatusOK, code)
+
+	for _, name := range []string{"core-01", "core-02"} {
+		_, err := sp.Enqueue(devices.NewDevice(name, []byte("hostname "+name+"\n")))
+		require.NoError(t, err)
+	}
+	code, body = check(t, h.readiness)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "ingest queue is saturated: 2 backups are waiting to be committed\n", body)
+}
+
+func TestPendingAPI(t *testing.T) {
+	api := &pendingHandler{}
+	ts := httptest.NewServer(api)
+	defer ts.Close()
+	client := server.NewVhsServiceProtobufClient(ts.URL, ts.Client())
+
+	_, err := client.ListDevices(context.Background(), &server.ListDevicesRequest{})
+	var twirpErr twirp.Error
+	require.ErrorAs(t, err, &twirpErr)
+	assert.Equal(t, twirp.Unavailable, twirpErr.Code())
+
+	handler, _, err := apiHandler(newTestServer(t), config.AuthConfig{})
+	require.NoError(t, err)
+	api.set(handler)
+	_, err = client.ListDevices(context.Background(), &server.ListDevicesRequest{})
+	assert.NoError(t, err)
+}
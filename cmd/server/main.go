@@ -2,57 +2,140 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
-	"time"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"vhs/backend"
+	"vhs/config"
 	"vhs/devices"
 	"vhs/git"
+	"vhs/jobs"
+	"vhs/normalize"
 	"vhs/pkg/vhs/server"
-)
-
-var deviceChan = make(chan devices.Device, 100) // Buffer size of 100, adjust as needed.
-
-type VhsServer struct {
-	VHS git.Git
-}
+	"vhs/spool"
+	"vhs/storage"
 
-func (v *VhsServer) Backup(ctx context.Context, request *server.BackupRequest) (*server.BackupResponse, error) {
-	dev := request.GetDevice()
-	deviceChan <- devices.NewDevice(dev.GetHost(), dev.GetPayload())
-	return &server.BackupResponse{
-		Success: true,
-		Status:  200,
-	}, nil
-}
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
 
-const repoURL = "git@github.com:metajar/testbackup.git"
+const trackedJobs = 10000
 
 func main() {
-	g := git.NewGit("/tmp/vhs", "main") // Set the path to your repo and the branch name.
-	if err := g.Clone(repoURL); err != nil {
-		log.Fatalf("Failed to clone repository: %v\n", err)
+	cfg, err := config.FromArgs(os.Args[0], os.Args[1:], os.LookupEnv)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v\n", err)
 	}
-	// Set up the upstream branch for the local branch.
-	if err := g.SetUpstreamBranch(); err != nil {
-		log.Fatalf("Failed to set upstream branch: %v\n", err)
+	// Backups are persisted here before they are acknowledged; leftovers from a previous run are replayed.
+	sp, err := spool.Open(cfg.Spool.Dir, cfg.Spool.MaxAttempts)
+	if err != nil {
+		log.Fatalf("Failed to open spool: %v\n", err)
 	}
-	// Pull the latest changes before starting the application.
-	if err := g.Pull(); err != nil {
-		log.Fatalf("Failed to pull changes: %v\n", err)
+	if n := sp.Len(); n > 0 {
+		log.Printf("Replaying %d spooled backups\n", n)
+	}
+	registry := newRegistry(sp)
+	tracker := jobs.NewTracker(trackedJobs)
+	syncs := newSyncTracker()
+
+	// Health checks are answered while the repository is opened, which can take a while.
+	health := newHealth(cfg.Health, sp, syncs)
+	api := &pendingHandler{}
+	mux := http.NewServeMux()
+	mux.Handle(server.VhsServicePathPrefix, api)
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/healthz", health.liveness)
+	mux.HandleFunc("/readyz", health.readiness)
+	httpServer := &http.Server{Addr: cfg.Listen, Handler: mux}
+	listen := httpServer.ListenAndServe
+	if cfg.TLS.Enabled() {
+		reloader, err := newTLSReloader(cfg.TLS)
+		if err != nil {
+			log.Fatalf("Failed to set up TLS: %v\n", err)
+		}
+		hangups := make(chan os.Signal, 1)
+		signal.Notify(hangups, syscall.SIGHUP)
+		go reloader.reloadOn(context.Background(), hangups)
+		httpServer.TLSConfig = reloader.Config()
+		listen = func() error { return httpServer.ListenAndServeTLS("", "") }
 	}
-	v := VhsServer{VHS: g}
 	go func() {
-		for device := range deviceChan {
-			if err := g.SaveDeviceConfiguration(device); err != nil {
-				log.Printf("Failed to save configuration for device %s: %v\n", device.Name, err)
-				continue
-			}
-			log.Printf("Saved configuration for device %s\n", device.Name)
+		if err := listen(); !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
 		}
 	}()
-	go g.StartPeriodicPush(context.Background(), time.Second*10, time.Second*60)
-	twirpHandler := server.NewVhsServiceServer(&v)
-	mux := http.NewServeMux()
-	mux.Handle(twirpHandler.PathPrefix(), twirpHandler)
-	http.ListenAndServe(":8080", mux)
+
+	health.setStarting("opening the repository")
+	backendStore, err := backend.Open(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open store: %v\n", err)
+	}
+	store, err := backend.Encrypted(cfg, backendStore)
+	if err != nil {
+		log.Fatalf("Failed to open store: %v\n", err)
+	}
+	classifier, err := devices.NewClassifier(cfg.Classification.Rules, cfg.Classification.Default)
+	if err != nil {
+		log.Fatalf("Failed to load classification rules: %v\n", err)
+	}
+	normalizer, err := normalize.NewNormalizer(cfg.Normalization.Rules)
+	if err != nil {
+		log.Fatalf("Failed to load normalization rules: %v\n", err)
+	}
+	redactor, err := cfg.Redaction.Redactor()
+	if err != nil {
+		log.Fatalf("Failed to load redaction rules: %v\n", err)
+	}
+	scanner, err := cfg.Scanning.Scanner()
+	if err != nil {
+		log.Fatalf("Failed to load scanning rules: %v\n", err)
+	}
+	v := VhsServer{Store: store, Spool: sp, Jobs: tracker, Syncs: syncs, Classifier: classifier, Normalizer: normalizer, Redactor: redactor, Scanner: scanner}
+	save := storeSaver(store)
+	if g, ok := backendStore.(*git.Git); ok && cfg.Repository.BatchWindow > 0 {
+		save = git.NewBatcher(g, cfg.Repository.BatchWindow, cfg.Repository.BatchSize).Add
+		if encrypted, ok := store.(*storage.EncryptedStore); ok {
+			save = encryptingSaver(encrypted, save)
+		}
+	}
+	handler, _, err := apiHandler(&v, cfg.Auth)
+	if err != nil {
+		log.Fatalf("Failed to set up authentication: %v\n", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var background sync.WaitGroup
+	background.Add(2)
+	go func() {
+		defer background.Done()
+		newWorker(sp, save, tracker).run(ctx)
+	}()
+	go func() {
+		defer background.Done()
+		periodicSync(ctx, store, cfg.Repository.PushInterval, cfg.Repository.DeprecationAge, tracker, syncs)
+	}()
+	api.set(handler)
+	health.setStarting("")
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	log.Printf("Received %s\n", <-signals)
+	// A second signal stops the server right away.
+	signal.Stop(signals)
+	shutdown{
+		cfg:    cfg.Shutdown,
+		http:   httpServer,
+		spool:  sp,
+		health: health,
+		stop: func() {
+			cancel()
+			background.Wait()
+		},
+		push: func() error {
+			_, err := push(store, cfg.Repository.PushInterval, tracker, syncs)
+			return err
+		},
+	}.run()
 }
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"vhs/devices"
+	"vhs/pkg/vhs/server"
+	"vhs/storage"
+
+	"github.com/twitchtv/twirp"
+)
+
+// parseMetadata converts the metadata sent with a backup. The collection time defaults to
+// received, blank tags are dropped. Values with control characters are rejected, they end up
+// in YAML sidecars and commit trailers where a newline could forge further lines.
+func parseMetadata(metadata *server.DeviceMetadata, received time.Time) (devices.Metadata, error) {
+	fields := []struct{ name, value string }{
+		{"vendor", metadata.GetVendor()},
+		{"os", metadata.GetOs()},
+		{"os_version", metadata.GetOsVersion()},
+		{"model", metadata.GetModel()},
+		{"serial", metadata.GetSerial()},
+		{"site", metadata.GetSite()},
+		{"role", metadata.GetRole()},
+		{"collector", metadata.GetCollector()},
+	}
+	for _, tag := range metadata.GetTags() {
+		fields = append(fields, struct{ name, value string }{"tags", tag})
+	}
+	for _, field := range fields {
+		if strings.IndexFunc(strings.TrimSpace(field.value), unicode.IsControl) >= 0 {
+			return devices.Metadata{}, twirp.InvalidArgumentError("device.metadata."+field.name, "must not contain control characters")
+		}
+	}
+
+	m := devices.Metadata{
+		Vendor:      strings.TrimSpace(metadata.GetVendor()),
+		OS:          strings.TrimSpace(metadata.GetOs()),
+		OSVersion:   strings.TrimSpace(metadata.GetOsVersion()),
+		Model:       strings.TrimSpace(metadata.GetModel()),
+		Serial:      strings.TrimSpace(metadata.GetSerial()),
+		Site:        strings.TrimSpace(metadata.GetSite()),
+		Role:        strings.TrimSpace(metadata.GetRole()),
+		CollectedAt: received,
+		Collector:   strings.TrimSpace(metadata.GetCollector()),
+	}
+	for _, tag := range metadata.GetTags() {
+		if tag = strings.TrimSpace(tag); tag != "" && !m.HasTag(tag) {
+			m.Tags = append(m.Tags, tag)
+		}
+	}
+	if collectedAt := metadata.GetCollectedAt(); collectedAt != "" {
+		t, err := time.Parse(time.RFC3339, collectedAt)
+		if err != nil {
+			return devices.Metadata{}, twirp.InvalidArgumentError("device.metadata.collected_at", "must be an RFC3339 time")
+		}
+		m.CollectedAt = t
+	}
+	return m, nil
+}
+
+func newDeviceMetadata(m devices.Metadata) *server.DeviceMetadata {
+	return &server.DeviceMetadata{
+		Vendor:      m.Vendor,
+		Os:          m.OS,
+		OsVersion:   m.OSVersion,
+		Model:       m.Model,
+		Serial:      m.Serial,
+		Site:        m.Site,
+		Role:        m.Role,
+		Tags:        m.Tags,
+		CollectedAt: formatTimestamp(m.CollectedAt),
+		Collector:   m.Collector,
+	}
+}
+
+// matchesFilter reports whether a device matches every field set in a ListDevices request.
+func matchesFilter(info storage.DeviceInfo, request *server.ListDevicesRequest) bool {
+	m := info.Metadata
+	fields := []struct{ want, have string }{
+		{request.GetDeviceType(), info.DeviceType},
+		{request.GetVendor(), m.Vendor},
+		{request.GetOs(), m.OS},
+		{request.GetOsVersion(), m.OSVersion},
+		{request.GetModel(), m.Model},
+		{request.GetSite(), m.Site},
+		{request.GetRole(), m.Role},
+		{request.GetCollector(), m.Collector},
+	}
+	for _, field := range fields {
+		if field.want != "" && field.want != field.have {
+			return false
+		}
+	}
+	for _, tag := range request.GetTags() {
+		if !m.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
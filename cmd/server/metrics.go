@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"vhs/git"
+	"vhs/pkg/vhs/server"
+	"vhs/spool"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/twitchtv/twirp"
+)
+
+// durationBuckets are histogram bucket upper bounds, in seconds, suited to the duration of
+// commits, which include the wait for their batch.
+var durationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60}
+
+var (
+	backupRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "vhs_backup_requests_total",
+		Help: "Backup calls by outcome: accepted, committed, or the Twirp error code they failed with.",
+	}, []string{"outcome"})
+	saveDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "vhs_save_duration_seconds",
+		Help:    "Time taken to commit a spooled backup, including the wait for its batch.",
+		Buckets: durationBuckets,
+	})
+	pushes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "vhs_pushes_total",
+		Help: "Syncs with the remote by outcome: success or failure.",
+	}, []string{"outcome"})
+	lastPush = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "vhs_last_successful_push_timestamp_seconds",
+		Help: "Unix time of the last successful sync with the remote.",
+	})
+	deprecations = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "vhs_deprecated_configurations_total",
+		Help: "Configurations moved to the deprecated folder.",
+	})
+)
+
+// newRegistry returns the registry served on /metrics, holding the server's metrics, those
+// of the git backend and the depth of sp.
+func newRegistry(sp *spool.Spool) *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(backupRequests, saveDuration, pushes, lastPush, deprecations, git.CommandDuration)
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "vhs_queue_depth",
+		Help: "Backups spooled and not committed yet.",
+	}, func() float64 {
+		return float64(sp.Len())
+	}))
+	return registry
+}
+
+// countBackups counts Backup calls by outcome, including the ones the interceptors after
+// it reject.
+func countBackups(next twirp.Method) twirp.Method {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		response, err := next(ctx, request)
+		if method, _ := twirp.MethodName(ctx); method == "Backup" {
+			backupRequests.WithLabelValues(backupOutcome(response, err)).Inc()
+		}
+		return response, err
+	}
+}
+
+func backupOutcome(response interface{}, err error) string {
+	var twirpErr twirp.Error
+	if errors.As(err, &twirpErr) {
+		return string(twirpErr.Code())
+	}
+	if err != nil {
+		return string(twirp.Internal)
+	}
+	if r, ok := response.(*server.BackupResponse); ok && r.GetCommit() != "" {
+		return "committed"
+	}
+	return "accepted"
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"vhs/config"
+	"vhs/pkg/vhs/server"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sample returns the value of the sample named series, with its labels, served on /metrics.
+func sample(t *testing.T, url string, series string) float64 {
+	t.Helper()
+	response, err := http.Get(url + "/metrics")
+	require.NoError(t, err)
+	defer response.Body.Close()
+	scanner := bufio.NewScanner(response.Body)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), series+" "); ok {
+			v, err := strconv.ParseFloat(value, 64)
+			require.NoError(t, err)
+			return v
+		}
+	}
+	return 0
+}
+
+func TestBackupMetrics(t *testing.T) {
+	v := newTestServer(t)
+	handler, prefix, err := apiHandler(v, config.AuthConfig{})
+	require.NoError(t, err)
+	mux := http.NewServeMux()
+	mux.Handle(prefix, handler)
+	mux.Handle("/metrics", promhttp.HandlerFor(newRegistry(v.Spool), promhttp.HandlerOpts{}))
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	client := server.NewVhsServiceProtobufClient(ts.URL, ts.Client())
+
+	committed := sample(t, ts.URL, `vhs_backup_requests_total{outcome="committed"}`)
+	accepted := sample(t, ts.URL, `vhs_backup_requests_total{outcome="accepted"}`)
+	invalid := sample(t, ts.URL, `vhs_backup_requests_total{outcome="invalid_argument"}`)
+	saves := sample(t, ts.URL, "vhs_save_duration_seconds_count")
+
+	device := &server.Device{Host: "core-01", Payload: []byte("hostname core-01\n")}
+	_, err = client.Backup(context.Background(), &server.BackupRequest{Device: device, Mode: server.BackupMode_BACKUP_MODE_SYNC})
+	require.NoError(t, err)
+	_, err = client.Backup(context.Background(), &server.BackupRequest{Device: device})
+	require.NoError(t, err)
+	_, err = client.Backup(context.Background(), &server.BackupRequest{Device: &server.Device{Payload: device.Payload}})
+	require.Error(t, err)
+
+	assert.Equal(t, committed+1, sample(t, ts.URL, `vhs_backup_requests_total{outcome="committed"}`))
+	assert.Equal(t, accepted+1, sample(t, ts.URL, `vhs_backup_requests_total{outcome="accepted"}`))
+	assert.Equal(t, invalid+1, sample(t, ts.URL, `vhs_backup_requests_total{outcome="invalid_argument"}`))
+	assert.GreaterOrEqual(t, sample(t, ts.URL, "vhs_save_duration_seconds_count"), saves+1)
+}
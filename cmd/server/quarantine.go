@@ -0,0 +1,27 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"vhs/devices"
+	"vhs/scan"
+
+	"github.com/twitchtv/twirp"
+)
+
+// quarantine sets aside a backup the scanner found possible secrets in and returns the
+// error telling the collector it was not stored. The findings locate the secrets without
+// repeating them, so they are safe to log and return.
+func (v *VhsServer) quarantine(device devices.Device, findings []scan.Finding) error {
+	reasons := make([]string, len(findings))
+	for i, f := range findings {
+		reasons[i] = f.String()
+	}
+	reason := "possible secrets: " + strings.Join(reasons, "; ")
+	id, err := v.Spool.Quarantine(device, reason)
+	if err != nil {
+		return spoolError(err)
+	}
+	log.Printf("Quarantined backup of %s as %s, %s\n", device.Name, id, reason)
+	return twirp.NewError(twirp.FailedPrecondition, "backup was quarantined, it contains "+reason).WithMeta("quarantine_id", id)
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"vhs/auth"
+	"vhs/devices"
+	"vhs/encrypt"
+	"vhs/jobs"
+	"vhs/normalize"
+	"vhs/pkg/vhs/server"
+	"vhs/redact"
+	"vhs/scan"
+	"vhs/spool"
+	"vhs/storage"
+
+	"github.com/twitchtv/twirp"
+)
+
+const (
+	defaultHistoryPageSize = 50
+	maxHistoryPageSize     = 1000
+)
+
+var jobStates = map[jobs.State]server.JobState{
+	jobs.Queued:    server.JobState_JOB_STATE_QUEUED,
+	jobs.Committed: server.JobState_JOB_STATE_COMMITTED,
+	jobs.Pushed:    server.JobState_JOB_STATE_PUSHED,
+	jobs.Failed:    server.JobState_JOB_STATE_FAILED,
+}
+
+type VhsServer struct {
+	Store storage.Store
+	Spool *spool.Spool
+	Jobs  *jobs.Tracker
+	Syncs *syncTracker
+	// Classifier types incoming devices. Without one devices are typed by the default rules.
+	Classifier *devices.Classifier
+	// Normalizer strips volatile content from incoming backups. Without one backups are
+	// stored as they are sent.
+	Normalizer *normalize.Normalizer
+	// Redactor removes secrets from incoming backups, whatever the collector did, before
+	// they reach the spool. Without one backups are stored as they are sent.
+	Redactor *redact.Redactor
+	// Scanner looks for secrets left in incoming backups after redaction. Backups with
+	// findings are quarantined instead of committed. Without one every backup is committed.
+	Scanner *scan.Scanner
+}
+
+func (v *VhsServer) Backup(ctx context.Context, request *server.BackupRequest) (*server.BackupResponse, error) {
+	dev := request.GetDevice()
+	name, err := deviceName("device.host", dev.GetHost())
+	if err != nil {
+		return nil, err
+	}
+	device := devices.NewDevice(name, dev.GetPayload())
+	device.Attributes = dev.GetAttributes()
+	if device.Metadata, err = parseMetadata(dev.GetMetadata(), time.Now()); err != nil {
+		return nil, err
+	}
+	// A verified client certificate says who collected the backup better than the
+	// collector itself.
+	if collector, ok := auth.CollectorFromContext(ctx); ok {
+		device.Metadata.Collector = collector
+	}
+	if device.Artifacts, err = parseArtifacts(dev.GetArtifacts()); err != nil {
+		return nil, err
+	}
+	if v.Classifier != nil {
+		device.Type = v.Classifier.Classify(device.Name, device.Attributes)
+	}
+	if v.Redactor != nil {
+		device = v.Redactor.RedactDevice(device)
+	}
+	// Normalize before spooling, so that the store only sees changes that matter.
+	if v.Normalizer != nil {
+		device = v.Normalizer.NormalizeDevice(device)
+	}
+	if v.Scanner != nil {
+		if findings := v.Scanner.ScanDevice(device); len(findings) > 0 {
+			return nil, v.quarantine(device, findings)
+		}
+	}
+	// Only acknowledge the backup once it is safely on disk.
+	id, err := v.Spool.Enqueue(device)
+	if err != nil {
+		return nil, spoolError(err)
+	}
+	v.Jobs.Queued(id, name)
+	if request.GetMode() != server.BackupMode_BACKUP_MODE_SYNC {
+		return &server.BackupResponse{
+			Success: true,
+			Status:  http.StatusAccepted,
+			JobId:   id,
+		}, nil
+	}
+
+	// Failed attempts are retried from the spool, so only a commit or the final failure
+	// answers the collector; answering earlier would have it send the backup again.
+	job, err := v.Jobs.Wait(ctx, id)
+	if err != nil {
+		// The backup stays spooled, the collector can keep polling its status.
+		return nil, twirp.NewError(twirp.DeadlineExceeded, "backup was not committed in time").WithMeta("job_id", id)
+	}
+	if job.State != jobs.Committed && job.State != jobs.Pushed {
+		return nil, twirp.InternalError(job.Error).WithMeta("job_id", id)
+	}
+	return &server.BackupResponse{
+		Success: true,
+		Status:  http.StatusOK,
+		JobId:   id,
+		Commit:  job.Commit,
+	}, nil
+}
+
+func (v *VhsServer) GetBackupStatus(ctx context.Context, request *server.GetBackupStatusRequest) (*server.GetBackupStatusResponse, error) {
+	if request.GetJobId() == "" {
+		return nil, twirp.RequiredArgumentError("job_id")
+	}
+	job, ok := v.Jobs.Get(request.GetJobId())
+	if !ok {
+		return nil, twirp.NotFoundError("unknown job " + request.GetJobId())
+	}
+	return &server.GetBackupStatusResponse{
+		JobId:     job.ID,
+		Host:      job.Device,
+		State:     jobStates[job.State],
+		Commit:    job.Commit,
+		Error:     job.Error,
+		Attempts:  int32(job.Attempts),
+		UpdatedAt: formatTimestamp(job.Updated),
+	}, nil
+}
+
+func (v *VhsServer) GetSyncStatus(ctx context.Context, request *server.GetSyncStatusRequest) (*server.GetSyncStatusResponse, error) {
+	status, err := v.Store.SyncStatus()
+	if err != nil {
+		return nil, twirp.InternalErrorWith(err)
+	}
+	syncs := v.Syncs.Status()
+	return &server.GetSyncStatusResponse{
+		Ahead:               int32(status.Ahead),
+		Behind:              int32(status.Behind),
+		LastAttempt:         formatTimestamp(syncs.LastAttempt),
+		LastSuccess:         formatTimestamp(syncs.LastSuccess),
+		NextAttempt:         formatTimestamp(syncs.NextAttempt),
+		LastError:           syncs.LastError,
+		ConsecutiveFailures: int32(syncs.Failures),
+	}, nil
+}
+
+func (v *VhsServer) ListDevices(ctx context.Context, request *server.ListDevicesRequest) (*server.ListDevicesResponse, error) {
+	infos, err := v.Store.List()
+	if err != nil {
+		return nil, twirp.InternalErrorWith(err)
+	}
+	response := &server.ListDevicesResponse{}
+	for _, info := range infos {
+		if !matchesFilter(info, request) {
+			continue
+		}
+		response.Devices = append(response.Devices, &server.DeviceSummary{
+			Host:       info.Name,
+			DeviceType: info.DeviceType,
+			LastBackup: formatTimestamp(info.LastBackup),
+			Metadata:   newDeviceMetadata(info.Metadata),
+			Artifacts:  info.Artifacts,
+		})
+	}
+	return response, nil
+}
+
+func (v *VhsServer) GetLatestBackup(ctx context.Context, request *server.GetLatestBackupRequest) (*server.GetBackupResponse, error) {
+	name, err := backupName(request.GetHost(), request.GetArtifact())
+	if err != nil {
+		return nil, err
+	}
+	revision, err := v.Store.Get(name, storage.Query{})
+	if err != nil {
+		return nil, backupError(err)
+	}
+	return newGetBackupResponse(revision), nil
+}
+
+func (v *VhsServer) GetBackupAt(ctx context.Context, request *server.GetBackupAtRequest) (*server.GetBackupResponse, error) {
+	name, err := backupName(request.GetHost(), request.GetArtifact())
+	if err != nil {
+		return nil, err
+	}
+	var query storage.Query
+	switch r := request.GetRevision().(type) {
+	case *server.GetBackupAtRequest_Timestamp:
+		at, err := time.Parse(time.RFC3339, r.Timestamp)
+		if err != nil {
+			return nil, twirp.InvalidArgumentError("timestamp", "must be an RFC3339 time")
+		}
+		query.At = at
+	case *server.GetBackupAtRequest_Commit:
+		if r.Commit == "" {
+			return nil, twirp.RequiredArgumentError("commit")
+		}
+		query.Revision = r.Commit
+	default:
+		return nil, twirp.RequiredArgumentError("timestamp or commit")
+	}
+	revision, err := v.Store.Get(name, query)
+	if err != nil {
+		return nil, backupError(err)
+	}
+	return newGetBackupResponse(revision), nil
+}
+
+func (v *VhsServer) GetDeviceHistory(ctx context.Context, request *server.GetDeviceHistoryRequest) (*server.GetDeviceHistoryResponse, error) {
+	name, err := backupName(request.GetHost(), request.GetArtifact())
+	if err != nil {
+		return nil, err
+	}
+	opts := storage.HistoryOptions{Limit: defaultHistoryPageSize}
+	if request.GetPageSize() < 0 || request.GetPageSize() > maxHistoryPageSize {
+		return nil, twirp.InvalidArgumentError("page_size", fmt.Sprintf("must be between 0 and %d", maxHistoryPageSize))
+	}
+	if request.GetPageSize() > 0 {
+		opts.Limit = int(request.GetPageSize())
+	}
+	if request.GetPageToken() != "" {
+		skip, err := strconv.Atoi(request.GetPageToken())
+		if err != nil || skip < 0 {
+			return nil, twirp.InvalidArgumentError("page_token", "is not a valid page token")
+		}
+		opts.Skip = skip
+	}
+	if opts.Since, err = parseOptionalTimestamp(request.GetSince()); err != nil {
+		return nil, twirp.InvalidArgumentError("since", "must be an RFC3339 time")
+	}
+	if opts.Until, err = parseOptionalTimestamp(request.GetUntil()); err != nil {
+		return nil, twirp.InvalidArgumentError("until", "must be an RFC3339 time")
+	}
+
+	entries, more, err := v.Store.History(name, opts)
+	if err != nil {
+		return nil, twirp.InternalErrorWith(err)
+	}
+	response := &server.GetDeviceHistoryResponse{}
+	for _, entry := range entries {
+		response.Entries = append(response.Entries, &server.HistoryEntry{
+			Commit:      entry.Commit,
+			Timestamp:   formatTimestamp(entry.Timestamp),
+			Message:     entry.Message,
+			Author:      entry.Author,
+			PayloadSize: entry.PayloadSize,
+		})
+	}
+	if more {
+		response.NextPageToken = strconv.Itoa(opts.Skip + len(entries))
+	}
+	return response, nil
+}
+
+func (v *VhsServer) DiffBackup(ctx context.Context, request *server.DiffBackupRequest) (*server.DiffBackupResponse, error) {
+	name, err := backupName(request.GetHost(), request.GetArtifact())
+	if err != nil {
+		return nil, err
+	}
+	if request.GetFrom() == nil && request.GetTo() != nil {
+		return nil, twirp.RequiredArgumentError("from")
+	}
+	to, err := v.resolveRevision(name, request.GetTo(), "to")
+	if err != nil {
+		return nil, err
+	}
+	var from storage.Revision
+	if request.GetFrom() == nil {
+		from, err = v.Store.Get(name, storage.Query{Revision: to.Commit, Previous: true})
+		if err != nil {
+			return nil, backupError(err)
+		}
+	} else if from, err = v.resolveRevision(name, request.GetFrom(), "from"); err != nil {
+		return nil, err
+	}
+	diff, err := v.Store.Diff(name, from.Commit, to.Commit)
+	if err != nil {
+		return nil, backupError(err)
+	}
+	return &server.DiffBackupResponse{
+		FromCommit: from.Commit,
+		ToCommit:   to.Commit,
+		Diff:       diff,
+	}, nil
+}
+
+// deviceName normalizes the device name passed in argument, rejecting names that are not
+// safe to use as file names.
+func deviceName(argument string, host string) (string, error) {
+	if host == "" {
+		return "", twirp.RequiredArgumentError(argument)
+	}
+	name, err := devices.NormalizeName(host)
+	var invalid *devices.InvalidNameError
+	if errors.As(err, &invalid) {
+		return "", twirp.InvalidArgumentError(argument, invalid.Reason)
+	}
+	return name, err
+}
+
+// resolveRevision looks up the backup a BackupRevision refers to, defaulting to the latest one.
+func (v *VhsServer) resolveRevision(name string, revision *server.BackupRevision, argument string) (storage.Revision, error) {
+	var query storage.Query
+	switch r := revision.GetRevision().(type) {
+	case *server.BackupRevision_Timestamp:
+		at, err := time.Parse(time.RFC3339, r.Timestamp)
+		if err != nil {
+			return storage.Revision{}, twirp.InvalidArgumentError(argument+".timestamp", "must be an RFC3339 time")
+		}
+		query.At = at
+	case *server.BackupRevision_Commit:
+		query.Revision = r.Commit
+	}
+	result, err := v.Store.Get(name, query)
+	if err != nil {
+		return storage.Revision{}, backupError(err)
+	}
+	return result, nil
+}
+
+func newGetBackupResponse(revision storage.Revision) *server.GetBackupResponse {
+	host, artifact := storage.SplitName(revision.Device)
+	return &server.GetBackupResponse{
+		Device: &server.Device{
+			Host:    host,
+			Payload: revision.Payload,
+		},
+		Commit:    revision.Commit,
+		Timestamp: formatTimestamp(revision.Timestamp),
+		Artifact:  artifact,
+	}
+}
+
+// backupError maps storage errors onto Twirp error codes.
+func backupError(err error) error {
+	if errors.Is(err, storage.ErrNotFound) {
+		return twirp.NotFoundError(err.Error())
+	}
+	if errors.Is(err, encrypt.ErrNoIdentity) {
+		return twirp.NewError(twirp.FailedPrecondition, "backups are encrypted and the server has no identity to decrypt them")
+	}
+	return twirp.InternalErrorWith(err)
+}
+
+// spoolError turns backups away with an unavailable error once the spool is closed for
+// shutdown, so collectors retry them against another instance or after the restart.
+func spoolError(err error) error {
+	if errors.Is(err, spool.ErrClosed) {
+		return twirp.NewError(twirp.Unavailable, "server is shutting down")
+	}
+	return twirp.InternalErrorWith(err)
+}
+
+func parseOptionalTimestamp(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func formatTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
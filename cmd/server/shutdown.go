@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"vhs/config"
+	"vhs/spool"
+)
+
+// shutdown stops the server without losing backups: new ones are turned away, the spooled
+// ones are committed, the API stops once the requests in flight are answered, and what was
+// committed is pushed.
+type shutdown struct {
+	cfg    config.ShutdownConfig
+	http   *http.Server
+	spool  *spool.Spool
+	health *health
+	// stop cancels the spool worker and the periodic sync and waits for them to return.
+	stop func()
+	// push syncs the store with its remote one last time.
+	push func() error
+}
+
+func (s shutdown) run() {
+	s.health.setStopping()
+	s.spool.Close()
+	log.Printf("Shutting down, committing %d spooled backups\n", s.spool.Len())
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.DrainTimeout)
+	defer cancel()
+	if err := s.spool.Drain(ctx); err != nil {
+		log.Printf("%d spooled backups were not committed in time, they are committed on the next start\n", s.spool.Len())
+	}
+	// Backups waiting to be committed were answered by now, nothing else takes long.
+	if err := s.http.Shutdown(ctx); err != nil {
+		s.http.Close()
+	}
+
+	pushCtx, cancelPush := context.WithTimeout(context.Background(), s.cfg.PushTimeout)
+	defer cancelPush()
+	err := runUntil(pushCtx, func() error {
+		// A periodic sync in progress finishes first, so the two do not push concurrently.
+		s.stop()
+		return s.push()
+	})
+	if err != nil {
+		log.Printf("Final push failed: %v\n", err)
+		return
+	}
+	log.Println("Final push done")
+}
+
+// runUntil runs f and returns its error, or the error of ctx when ctx is done first. f is
+// left running in that case.
+func runUntil(ctx context.Context, f func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- f()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
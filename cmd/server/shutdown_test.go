@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+	"vhs/config"
+	"vhs/devices"
+	"vhs/jobs"
+	"vhs/pkg/vhs/server"
+	"vhs/spool"
+	"vhs/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twitchtv/twirp"
+)
+
+// slowStore is a MemoryStore whose saves take a while, so backups are still spooled when
+// the server is told to stop.
+type slowStore struct {
+	*storage.MemoryStore
+	delay time.Duration
+}
+
+func (s slowStore) Save(device devices.Device) (string, error) {
+	time.Sleep(s.delay)
+	return s.MemoryStore.Save(device)
+}
+
+func TestShutdown(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "vhs-spool")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	sp, err := spool.Open(tempDir, 1)
+	require.NoError(t, err)
+	store := slowStore{MemoryStore: storage.NewMemoryStore(), delay: 20 * time.Millisecond}
+	v := &VhsServer{Store: store, Spool: sp, Jobs: jobs.NewTracker(100), Syncs: newSyncTracker()}
+	for _, name := range []string{"core-01", "core-02", "core-03"} {
+		_, err := v.Backup(context.Background(), &server.BackupRequest{
+			Device: &server.Device{Host: name, Payload: []byte("hostname " + name + "\n")},
+		})
+		require.NoError(t, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var background sync.WaitGroup
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		newWorker(sp, storeSaver(store), v.Jobs).run(ctx)
+	}()
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+	h := newHealth(config.HealthConfig{}, sp, v.Syncs)
+	h.setStarting("")
+	var pushed []int
+	shutdown{
+		cfg:    config.ShutdownConfig{DrainTimeout: 5 * time.Second, PushTimeout: 5 * time.Second},
+		http:   ts.Config,
+		spool:  sp,
+		health: h,
+		stop: func() {
+			cancel()
+			background.Wait()
+		},
+		push: func() error {
+			infos, err := store.List()
+			pushed = append(pushed, len(infos))
+			return err
+		},
+	}.run()
+
+	assert.Zero(t, sp.Len())
+	assert.Equal(t, []int{3}, pushed, "every spooled backup is committed before the final push")
+	_, err = v.Backup(context.Background(), &server.BackupRequest{
+		Device: &server.Device{Host: "core-04", Payload: []byte("hostname core-04\n")},
+	})
+	var twirpErr twirp.Error
+	require.ErrorAs(t, err, &twirpErr)
+	assert.Equal(t, twirp.Unavailable, twirpErr.Code())
+	assert.Equal(t, []string{"shutting down"}, h.problems())
+	_, err = http.Get(ts.URL)
+	assert.Error(t, err, "the API is no longer served")
+}
+
+func TestShutdownDeadlines(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "vhs-spool")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	sp, err := spool.Open(tempDir, 1)
+	require.NoError(t, err)
+	// Nothing commits from this spool, so the drain runs into its deadline.
+	_, err = sp.Enqueue(devices.NewDevice("core-01", []byte("hostname core-01\n")))
+	require.NoError(t, err)
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	hung := make(chan struct{})
+	defer close(hung)
+	started := time.Now()
+	shutdown{
+		cfg:    config.ShutdownConfig{DrainTimeout: 50 * time.Millisecond, PushTimeout: 50 * time.Millisecond},
+		http:   ts.Config,
+		spool:  sp,
+		health: newHealth(config.HealthConfig{}, sp, newSyncTracker()),
+		stop:   func() {},
+		push: func() error {
+			<-hung
+			return errors.New("remote hung up")
+		},
+	}.run()
+	assert.Less(t, time.Since(started), time.Second)
+	assert.Equal(t, 1, sp.Len(), "the backup stays spooled for the next start")
+}
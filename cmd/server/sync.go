@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+	"vhs/jobs"
+	"vhs/storage"
+)
+
+const (
+	// syncRetryDelay is how long to wait before retrying a failed sync. It doubles with
+	// every further failure up to maxSyncRetryDelay.
+	syncRetryDelay    = time.Second
+	maxSyncRetryDelay = 5 * time.Minute
+)
+
+// syncStatus describes the outcome of the periodic syncs with the remote.
+type syncStatus struct {
+	LastAttempt time.Time
+	LastSuccess time.Time
+	NextAttempt time.Time
+	LastError   string
+	// Failures counts the failed syncs since the last successful one.
+	Failures int
+}
+
+// syncTracker records the outcome of the periodic syncs.
+type syncTracker struct {
+	mu     sync.Mutex
+	status syncStatus
+}
+
+func newSyncTracker() *syncTracker {
+	return &syncTracker{}
+}
+
+// Status returns the outcome of the latest syncs.
+func (t *syncTracker) Status() syncStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status
+}
+
+// scheduled records when the next sync is due.
+func (t *syncTracker) scheduled(next time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status.NextAttempt = next
+}
+
+// record records the outcome of a sync started at started and returns how long to wait
+// before the next one: interval after a success, an exponential backoff after failures.
+func (t *syncTracker) record(started time.Time, err error, interval time.Duration) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status.LastAttempt = started
+	if err != nil {
+		t.status.LastError = err.Error()
+		t.status.Failures++
+	} else {
+		t.status.LastSuccess = started
+		t.status.LastError = ""
+		t.status.Failures = 0
+	}
+	delay := syncDelay(interval, t.status.Failures)
+	t.status.NextAttempt = time.Now().Add(delay)
+	return delay
+}
+
+// syncDelay returns how long to wait before the next sync after failures failed ones.
+func syncDelay(interval time.Duration, failures int) time.Duration {
+	if failures == 0 {
+		return interval
+	}
+	delay := syncRetryDelay
+	for i := 1; i < failures && delay < maxSyncRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxSyncRetryDelay {
+		delay = maxSyncRetryDelay
+	}
+	return delay
+}
+
+// periodicSync deprecates stale configurations and syncs the store with its remote every
+// interval until ctx is done, retrying failed syncs with an exponential backoff. Jobs
+// committed before a successful sync are marked as pushed.
+func periodicSync(ctx context.Context, store storage.Store, interval time.Duration, maxAge time.Duration, tracker *jobs.Tracker, syncs *syncTracker) {
+	delay := interval
+	syncs.scheduled(time.Now().Add(delay))
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		deprecated, err := store.Deprecate(maxAge)
+		deprecations.Add(float64(deprecated))
+		if err != nil {
+			log.Printf("Failed to deprecate old files: %v\n", err)
+		}
+		if delay, err = push(store, interval, tracker, syncs); err != nil {
+			log.Printf("Failed to sync store, retrying in %s: %v\n", delay, err)
+		}
+	}
+}
+
+// push syncs the store with its remote and records the outcome. It returns how long to wait
+// before the next sync. Jobs committed before a successful sync are marked as pushed.
+func push(store storage.Store, interval time.Duration, tracker *jobs.Tracker, syncs *syncTracker) (time.Duration, error) {
+	started := time.Now()
+	err := store.Sync()
+	delay := syncs.record(started, err, interval)
+	if err != nil {
+		pushes.WithLabelValues("failure").Inc()
+		return delay, err
+	}
+	pushes.WithLabelValues("success").Inc()
+	lastPush.Set(float64(started.Unix()))
+	tracker.Pushed(started)
+	return delay, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+	"vhs/config"
+)
+
+// tlsReloader serves the certificate, key and client CAs named by the configuration and
+// reads them again on demand, so they can be rotated without a restart.
+type tlsReloader struct {
+	cfg config.TLSConfig
+
+	mu      sync.RWMutex
+	current *tls.Config
+}
+
+func newTLSReloader(cfg config.TLSConfig) (*tlsReloader, error) {
+	r := &tlsReloader{cfg: cfg}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reload reads the files again. On error the files loaded before stay in use.
+func (r *tlsReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	current := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+	if r.cfg.ClientCAFile != "" {
+		content, err := ioutil.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to load client CAs: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(content) {
+			return errors.New("failed to load client CAs: no certificates found in " + r.cfg.ClientCAFile)
+		}
+		current.ClientCAs = pool
+		current.ClientAuth = tls.VerifyClientCertIfGiven
+		if r.cfg.RequireClientCert {
+			current.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	r.mu.Lock()
+	r.current = current
+	r.mu.Unlock()
+	return nil
+}
+
+// Config returns the TLS configuration to serve with. Every connection uses the files
+// loaded last.
+func (r *tlsReloader) Config() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return r.current, nil
+		},
+	}
+}
+
+// reloadOn reloads the files whenever a signal arrives, until ctx is done.
+func (r *tlsReloader) reloadOn(ctx context.Context, signals <-chan os.Signal) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			if err := r.reload(); err != nil {
+				log.Printf("Failed to reload TLS files, keeping the previous ones: %v\n", err)
+				continue
+			}
+			log.Println("Reloaded TLS files")
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"vhs/config"
+	"vhs/pkg/vhs/server"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCA issues certificates for the TLS tests.
+type testCA struct {
+	cert   *x509.Certificate
+	key    *ecdsa.PrivateKey
+	serial int64
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "VHS test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, serial: 1}
+}
+
+// issue writes a certificate and key for template to <dir>/<name>.crt and .key.
+func (ca *testCA) issue(t *testing.T, dir string, name string, template *x509.Certificate) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ca.serial++
+	template.SerialNumber = big.NewInt(ca.serial)
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	require.NoError(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644))
+	require.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+func (ca *testCA) writeCert(t *testing.T, dir string) string {
+	t.Helper()
+	path := filepath.Join(dir, "ca.crt")
+	require.NoError(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0644))
+	return path
+}
+
+func serverTemplate(commonName string) *x509.Certificate {
+	return &x509.Certificate{
+		Subject:     pkix.Name{CommonName: commonName},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "vhs-tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	ca := newTestCA(t)
+	certFile, keyFile := ca.issue(t, tempDir, "server", serverTemplate("vhs-1"))
+	clientCert, clientKey := ca.issue(t, tempDir, "client", &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "collector"},
+		DNSNames:    []string{"ams-collector.example.com"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	reloader, err := newTLSReloader(config.TLSConfig{
+		CertFile:          certFile,
+		KeyFile:           keyFile,
+		ClientCAFile:      ca.writeCert(t, tempDir),
+		RequireClientCert: true,
+	})
+	require.NoError(t, err)
+
+	v := newTestServer(t)
+	handler, prefix, err := apiHandler(v, config.AuthConfig{})
+	require.NoError(t, err)
+	mux := http.NewServeMux()
+	mux.Handle(prefix, handler)
+	ts := httptest.NewUnstartedServer(mux)
+	ts.TLS = reloader.Config()
+	ts.StartTLS()
+	defer ts.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	pair, err := tls.LoadX509KeyPair(clientCert, clientKey)
+	require.NoError(t, err)
+	client := server.NewVhsServiceProtobufClient(ts.URL, &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{pair}},
+	}})
+	_, err = client.Backup(context.Background(), &server.BackupRequest{
+		Device: &server.Device{
+			Host:     "ams-sw-01",
+			Payload:  []byte("hostname ams-sw-01\n"),
+			Metadata: &server.DeviceMetadata{Collector: "someone-else"},
+		},
+		Mode: server.BackupMode_BACKUP_MODE_SYNC,
+	})
+	require.NoError(t, err)
+	list, err := client.ListDevices(context.Background(), &server.ListDevicesRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.Devices, 1)
+	assert.Equal(t, "ams-collector.example.com", list.Devices[0].Metadata.Collector, "the certificate identifies the collector")
+
+	anonymous := server.NewVhsServiceProtobufClient(ts.URL, &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{RootCAs: roots},
+	}})
+	_, err = anonymous.ListDevices(context.Background(), &server.ListDevicesRequest{})
+	assert.Error(t, err, "clients without a certificate are rejected")
+
+	// Rotated files are served once reloaded, by new connections.
+	ca.issue(t, tempDir, "server", serverTemplate("vhs-2"))
+	assert.Equal(t, "vhs-1", servedCommonName(t, ts.Listener.Addr().String(), roots, pair))
+	require.NoError(t, reloader.reload())
+	assert.Equal(t, "vhs-2", servedCommonName(t, ts.Listener.Addr().String(), roots, pair))
+
+	require.NoError(t, ioutil.WriteFile(keyFile, []byte("broken"), 0600))
+	assert.Error(t, reloader.reload())
+	assert.Equal(t, "vhs-2", servedCommonName(t, ts.Listener.Addr().String(), roots, pair), "a failed reload keeps the previous files")
+}
+
+func servedCommonName(t *testing.T, addr string, roots *x509.CertPool, pair tls.Certificate) string {
+	t.Helper()
+	conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{pair}})
+	require.NoError(t, err)
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+	"vhs/devices"
+	"vhs/jobs"
+	"vhs/spool"
+	"vhs/storage"
+)
+
+// spoolRetryDelay is how long the worker waits before retrying a backup that failed to commit.
+var spoolRetryDelay = 5 * time.Second
+
+// saveFunc commits a device's configuration and reports the outcome through done, which
+// may be called after saveFunc returns, e.g. when saves are batched.
+type saveFunc func(device devices.Device, done func(commit string, err error))
+
+// storeSaver saves every device with its own call to store.Save.
+func storeSaver(store storage.Store) saveFunc {
+	return func(device devices.Device, done func(commit string, err error)) {
+		done(store.Save(device))
+	}
+}
+
+// encryptingSaver encrypts devices for store before handing them to save, for savers that
+// write to the store underneath it, such as the git batcher.
+func encryptingSaver(store *storage.EncryptedStore, save saveFunc) saveFunc {
+	return func(device devices.Device, done func(commit string, err error)) {
+		encrypted, err := store.EncryptDevice(device)
+		if err != nil {
+			done("", err)
+			return
+		}
+		save(encrypted, done)
+	}
+}
+
+// worker commits spooled backups. An entry is only removed from the spool once its
+// configuration has been committed.
+type worker struct {
+	spool *spool.Spool
+	save  saveFunc
+	jobs  *jobs.Tracker
+
+	mu sync.Mutex
+	// retryAt holds off new saves after a failed one until the retry delay has passed.
+	retryAt time.Time
+}
+
+func newWorker(sp *spool.Spool, save saveFunc, tracker *jobs.Tracker) *worker {
+	return &worker{spool: sp, save: save, jobs: tracker}
+}
+
+// run hands spooled backups to the save function until ctx is done.
+func (w *worker) run(ctx context.Context) {
+	for {
+		w.mu.Lock()
+		wait := time.Until(w.retryAt)
+		w.mu.Unlock()
+		if wait > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+		}
+		entry, err := w.spool.Next(ctx)
+		if err != nil {
+			return
+		}
+		started := time.Now()
+		w.save(entry.Device, func(commit string, err error) {
+			saveDuration.Observe(time.Since(started).Seconds())
+			w.finish(entry, commit, err)
+		})
+	}
+}
+
+// finish records the outcome of saving a spooled backup.
+func (w *worker) finish(entry spool.Entry, commit string, err error) {
+	device := entry.Device
+	if err != nil {
+		log.Printf("Failed to save configuration for device %s: %v\n", device.Name, err)
+		dead, failErr := w.spool.Fail(entry.ID, err)
+		if failErr != nil {
+			log.Printf("Failed to record failure of spool entry %s: %v\n", entry.ID, failErr)
+		}
+		w.jobs.AttemptFailed(entry.ID, device.Name, err, dead)
+		if dead {
+			log.Printf("Moved spool entry %s for device %s to the dead-letter directory\n", entry.ID, device.Name)
+			return
+		}
+		w.mu.Lock()
+		w.retryAt = time.Now().Add(spoolRetryDelay)
+		w.mu.Unlock()
+		return
+	}
+	w.jobs.Committed(entry.ID, device.Name, commit)
+	if err := w.spool.Ack(entry.ID); err != nil {
+		log.Printf("Failed to remove spool entry %s: %v\n", entry.ID, err)
+	}
+	log.Printf("Saved configuration for device %s\n", device.Name)
+}
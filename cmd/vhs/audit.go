@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+	"vhs/backend"
+	"vhs/config"
+	"vhs/scan"
+)
+
+// auditSecrets scans every stored revision for secrets and prints the offending commits
+// by device. It fails when it finds any, so it can gate a pipeline.
+func auditSecrets(name string, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	cfg, err := config.Parse(fs, args, os.LookupEnv)
+	if err != nil {
+		return err
+	}
+	scanner, err := cfg.Scanning.Scanner()
+	if err != nil {
+		return err
+	}
+	store, err := backend.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to open store: %w", err)
+	}
+	if store, err = backend.Encrypted(cfg, store); err != nil {
+		return err
+	}
+	results, err := scan.Audit(store, scanner)
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		device := result.Device
+		if result.Artifact != "" {
+			device += " (" + result.Artifact + ")"
+		}
+		rules := make([]string, len(result.Findings))
+		for i, f := range result.Findings {
+			rules[i] = fmt.Sprintf("%s on line %d", f.Rule, f.Line)
+		}
+		fmt.Printf("%s %s %s: %s\n", device, result.Commit, result.Timestamp.Format(time.RFC3339), strings.Join(rules, ", "))
+	}
+	if len(results) > 0 {
+		return fmt.Errorf("found possible secrets in %d revisions", len(results))
+	}
+	log.Println("No secrets found")
+	return nil
+}
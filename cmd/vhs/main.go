@@ -0,0 +1,50 @@
+// Command vhs runs maintenance tasks against the repository of a VHS server. It reads the
+// same configuration file, environment variables and flags as the server.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+)
+
+// command is a vhs subcommand. run receives the arguments following the subcommand name.
+type command struct {
+	usage string
+	run   func(name string, args []string) error
+}
+
+var commands = map[string]command{
+	"audit-secrets":  {"scan every stored revision for secrets and report the offending commits", auditSecrets},
+	"migrate-layout": {"move stored configurations to the configured layout template", migrateLayout},
+	"show":           {"print a stored configuration or artifact, decrypted", show},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(os.Args[0]+" "+os.Args[1], os.Args[2:]); err != nil {
+		log.Fatalf("%s failed: %v\n", os.Args[1], err)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s <command> [flags]\n\ncommands:\n", os.Args[0])
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", name, commands[name].usage)
+	}
+}
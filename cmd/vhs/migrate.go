@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"vhs/backend"
+	"vhs/config"
+	"vhs/storage"
+)
+
+// migrateLayout moves the configurations laid out by the -from template to the configured
+// layout in a single commit, and pushes it.
+func migrateLayout(name string, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fromTemplate := fs.String("from", storage.DefaultTemplate, "template the repository is laid out by now")
+	dryRun := fs.Bool("dry-run", false, "print the moves without making them")
+	push := fs.Bool("push", true, "push the migration to the remote")
+	cfg, err := config.Parse(fs, args, os.LookupEnv)
+	if err != nil {
+		return err
+	}
+	from, err := storage.NewLayout(*fromTemplate, "")
+	if err != nil {
+		return fmt.Errorf("-from: %w", err)
+	}
+	to, err := backend.Layout(cfg)
+	if err != nil {
+		return err
+	}
+
+	store, err := backend.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to open store: %w", err)
+	}
+	migrator, ok := store.(storage.Migrator)
+	if !ok {
+		return fmt.Errorf("the %s backend cannot migrate layouts", cfg.Storage.Backend)
+	}
+	var moves []storage.Move
+	if *dryRun {
+		moves, err = migrator.PlanMigration(from)
+	} else {
+		moves, err = migrator.Migrate(from)
+	}
+	if err != nil {
+		return err
+	}
+	for _, move := range moves {
+		fmt.Printf("%s -> %s\n", move.From, move.To)
+	}
+	if *dryRun || len(moves) == 0 {
+		log.Printf("%d configurations to move from %s to %s\n", len(moves), from.Template(), to.Template())
+		return nil
+	}
+	log.Printf("Moved %d configurations from %s to %s\n", len(moves), from.Template(), to.Template())
+	if *push {
+		if err := store.Sync(); err != nil {
+			return fmt.Errorf("failed to push the migration: %w", err)
+		}
+	}
+	return nil
+}
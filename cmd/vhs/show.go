@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+	"vhs/backend"
+	"vhs/config"
+	"vhs/devices"
+	"vhs/storage"
+)
+
+// show prints a stored configuration or artifact, decrypted when encryption is configured.
+func show(name string, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	device := fs.String("device", "", "device to show the configuration of")
+	artifact := fs.String("artifact", "", "artifact to show instead of the configuration")
+	revision := fs.String("revision", "", "revision to show, the latest by default")
+	at := fs.String("at", "", "show the newest revision stored at or before this RFC3339 time")
+	cfg, err := config.Parse(fs, args, os.LookupEnv)
+	if err != nil {
+		return err
+	}
+	if *device == "" {
+		return errors.New("-device must be set")
+	}
+	query := storage.Query{Revision: *revision}
+	if *at != "" {
+		if query.At, err = time.Parse(time.RFC3339, *at); err != nil {
+			return errors.New("-at: must be an RFC3339 time")
+		}
+	}
+	store, err := backend.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to open store: %w", err)
+	}
+	if store, err = backend.Encrypted(cfg, store); err != nil {
+		return err
+	}
+	backupName := *device
+	if *artifact != "" {
+		backupName = storage.ArtifactName(*device, devices.ArtifactFileName(*artifact))
+	}
+	stored, err := store.Get(backupName, query)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(stored.Payload)
+	return err
+}
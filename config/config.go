@@ -0,0 +1,404 @@
+// Package config loads the VHS server configuration from a YAML file, environment
+// variables and command line flags, in increasing order of precedence.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strings"
+	"time"
+	"vhs/auth"
+	"vhs/devices"
+	"vhs/encrypt"
+	"vhs/normalize"
+	"vhs/redact"
+	"vhs/scan"
+	"vhs/storage"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds every setting of the VHS server.
+type Config struct {
+	// Listen is the address the Twirp API is served on.
+	Listen     string           `yaml:"listen"`
+	TLS        TLSConfig        `yaml:"tls"`
+	Storage    StorageConfig    `yaml:"storage"`
+	Repository RepositoryConfig `yaml:"repository"`
+	Spool      SpoolConfig      `yaml:"spool"`
+	Health     HealthConfig     `yaml:"health"`
+	Shutdown   ShutdownConfig   `yaml:"shutdown"`
+	// Classification decides which folder a device's configuration is stored in. It can
+	// only be set in the config file.
+	Classification ClassificationConfig `yaml:"classification"`
+	Layout         LayoutConfig         `yaml:"layout"`
+	// Normalization strips volatile content from backups before they are stored. It can
+	// only be set in the config file.
+	Normalization NormalizationConfig `yaml:"normalization"`
+	// Redaction removes secrets from backups before they are spooled. It can only be set
+	// in the config file.
+	Redaction RedactionConfig `yaml:"redaction"`
+	// Scanning looks for secrets redaction missed and quarantines the backups they are in.
+	// It can only be set in the config file.
+	Scanning ScanningConfig `yaml:"scanning"`
+	// Encryption encrypts what is stored, so the repository remote never sees a
+	// configuration in the clear.
+	Encryption EncryptionConfig `yaml:"encryption"`
+	// Auth restricts the API to clients with a configured token. Tokens can only be set in
+	// the config file.
+	Auth AuthConfig `yaml:"auth"`
+}
+
+// TLSConfig serves the API over TLS when CertFile and KeyFile are set. The files are read
+// again when the server receives SIGHUP.
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// ClientCAFile enables client certificates: certificates signed by one of its CAs are
+	// verified and identify the collector recorded with each backup. RequireClientCert
+	// rejects connections without one.
+	ClientCAFile      string `yaml:"client_ca_file"`
+	RequireClientCert bool   `yaml:"require_client_cert"`
+}
+
+// Enabled reports whether the API is served over TLS.
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+// Storage backends.
+const (
+	BackendGit        = "git"
+	BackendGoGit      = "go-git"
+	BackendFilesystem = "filesystem"
+)
+
+// StorageConfig selects where configurations are stored.
+type StorageConfig struct {
+	// Backend is "git", "go-git" or "filesystem". The go-git backend works like the git one
+	// without needing the git binary. The filesystem backend keeps every revision as a file
+	// below repository.dir and has no remote.
+	Backend string `yaml:"backend"`
+}
+
+// RepositoryConfig describes the git repository backups are stored in.
+type RepositoryConfig struct {
+	URL    string `yaml:"url"`
+	Dir    string `yaml:"dir"`
+	Branch string `yaml:"branch"`
+	// PushInterval is how often committed backups are pushed to the remote.
+	PushInterval time.Duration `yaml:"push_interval"`
+	// DeprecationAge is how old a configuration may get before it is moved to deprecated/.
+	DeprecationAge time.Duration `yaml:"deprecation_age"`
+	// SSHKeyFile, AuthorName and AuthorEmail are used by the go-git backend; the git
+	// backend relies on the git configuration of the user running VHS.
+	SSHKeyFile  string `yaml:"ssh_key_file"`
+	AuthorName  string `yaml:"author_name"`
+	AuthorEmail string `yaml:"author_email"`
+	// BatchWindow enables batched commits for the git backend: saves arriving within the
+	// window, up to BatchSize of them, are committed together. Zero commits every save on
+	// its own.
+	BatchWindow time.Duration `yaml:"batch_window"`
+	BatchSize   int           `yaml:"batch_size"`
+}
+
+// SpoolConfig describes the on-disk queue of backups waiting to be committed.
+type SpoolConfig struct {
+	Dir         string `yaml:"dir"`
+	MaxAttempts int    `yaml:"max_attempts"`
+}
+
+// HealthConfig decides when /readyz reports the server as not ready to take backups. It
+// is never ready while the repository is cloned or pulled at startup.
+type HealthConfig struct {
+	// MaxQueueDepth is how many spooled backups waiting to be committed saturate the
+	// server. Zero never considers it saturated.
+	MaxQueueDepth int `yaml:"max_queue_depth"`
+	// MaxPushFailures is how many consecutive failed pushes make the server unready until
+	// a push succeeds again. Zero ignores push failures.
+	MaxPushFailures int `yaml:"max_push_failures"`
+}
+
+// ShutdownConfig bounds how long the server takes to stop on SIGTERM or SIGINT.
+type ShutdownConfig struct {
+	// DrainTimeout is how long spooled backups may take to be committed. Backups left in
+	// the spool after it are committed on the next start.
+	DrainTimeout time.Duration `yaml:"drain_timeout"`
+	// PushTimeout is how long the final push may take.
+	PushTimeout time.Duration `yaml:"push_timeout"`
+}
+
+// ClassificationConfig types devices by the first matching rule. Devices no rule matches
+// get the Default type.
+type ClassificationConfig struct {
+	Rules   []devices.Rule `yaml:"rules"`
+	Default string         `yaml:"default"`
+}
+
+// LayoutConfig decides where in the repository a device's configuration is stored.
+type LayoutConfig struct {
+	// Template is a slash separated path such as "{site}/{role}/{hostname}.cfg". {hostname}
+	// is the device name, {type} its classified type, and any other {field} the attribute of
+	// that name sent with the backup, the metadata field of that name (vendor, site, role,
+	// ...), or else the field of that name in InventoryFile.
+	Template      string `yaml:"template"`
+	InventoryFile string `yaml:"inventory_file"`
+}
+
+// NormalizationConfig lists the rules normalizing the configuration and artifacts of a
+// backup. Every matching rule is applied; an empty list stores backups as they are sent.
+type NormalizationConfig struct {
+	Rules []normalize.Rule `yaml:"rules"`
+}
+
+// RedactionConfig lists redaction rules applied on top of the built-in vendor rule sets,
+// which cannot be turned off.
+type RedactionConfig struct {
+	Rules []redact.Rule `yaml:"rules"`
+	// Mode is "placeholder" to replace every secret by the same placeholder or
+	// "fingerprint" to replace it by a fingerprint keyed with the contents of
+	// FingerprintKeyFile, so secret rotations show up in diffs.
+	Mode               string `yaml:"mode"`
+	FingerprintKeyFile string `yaml:"fingerprint_key_file"`
+}
+
+// AllRules returns the built-in redaction rules followed by the configured ones.
+func (c RedactionConfig) AllRules() []redact.Rule {
+	return append(redact.DefaultRules(), c.Rules...)
+}
+
+// Redactor builds the Redactor applying AllRules in the configured mode.
+func (c RedactionConfig) Redactor() (*redact.Redactor, error) {
+	switch c.Mode {
+	case redact.ModePlaceholder:
+		return redact.NewRedactor(c.AllRules())
+	case redact.ModeFingerprint:
+		if c.FingerprintKeyFile == "" {
+			return nil, errors.New("fingerprint_key_file: must be set for the fingerprint mode")
+		}
+		key, err := ioutil.ReadFile(c.FingerprintKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("fingerprint_key_file: %w", err)
+		}
+		return redact.NewFingerprintRedactor(c.AllRules(), bytes.TrimSpace(key))
+	default:
+		return nil, fmt.Errorf("mode: must be %q or %q, got %q", redact.ModePlaceholder, redact.ModeFingerprint, c.Mode)
+	}
+}
+
+// ScanningConfig lists secret scanning rules applied on top of the built-in ones, and
+// tunes the check for high-entropy strings.
+type ScanningConfig struct {
+	Rules []scan.Rule `yaml:"rules"`
+	// Allow lists patterns of lines that are never flagged, for known false positives.
+	Allow   []string     `yaml:"allow"`
+	Entropy scan.Entropy `yaml:"entropy"`
+}
+
+// Scanner builds the Scanner applying the built-in rules followed by the configured ones.
+func (c ScanningConfig) Scanner() (*scan.Scanner, error) {
+	return scan.NewScanner(append(scan.DefaultRules(), c.Rules...), c.Allow, c.Entropy)
+}
+
+// EncryptionConfig lists the OpenPGP keys stored configurations and artifacts are encrypted
+// to. Encryption is off while RecipientFiles is empty.
+type EncryptionConfig struct {
+	// RecipientFiles are armored public keys; every one of them can decrypt the backups.
+	RecipientFiles []string `yaml:"recipient_files"`
+	// IdentityFile is the armored private key, without passphrase, of one of the recipients.
+	// The server needs it to serve reads and to tell whether a backup changed.
+	IdentityFile string `yaml:"identity_file"`
+}
+
+// Cipher loads the configured keys. It returns nil when encryption is off.
+func (c EncryptionConfig) Cipher() (*encrypt.Cipher, error) {
+	if len(c.RecipientFiles) == 0 {
+		if c.IdentityFile != "" {
+			return nil, errors.New("identity_file: recipient_files must be set as well")
+		}
+		return nil, nil
+	}
+	return encrypt.Load(c.RecipientFiles, c.IdentityFile)
+}
+
+// AuthConfig lists the API tokens. Without tokens the API accepts every request.
+type AuthConfig struct {
+	Tokens []auth.Token `yaml:"tokens"`
+	// AuditLogFile receives a line for every rejected request; empty logs to stderr.
+	AuditLogFile string `yaml:"audit_log_file"`
+}
+
+// Default returns the configuration used for settings that are not configured otherwise.
+func Default() *Config {
+	return &Config{
+		Listen: ":8080",
+		Storage: StorageConfig{
+			Backend: BackendGit,
+		},
+		Repository: RepositoryConfig{
+			Dir:            "/tmp/vhs",
+			Branch:         "main",
+			PushInterval:   10 * time.Second,
+			DeprecationAge: 60 * time.Second,
+			AuthorName:     "VHS",
+			AuthorEmail:    "vhs@localhost",
+			BatchSize:      500,
+		},
+		Spool: SpoolConfig{
+			Dir:         "/tmp/vhs-spool",
+			MaxAttempts: 5,
+		},
+		Health: HealthConfig{
+			MaxQueueDepth:   1000,
+			MaxPushFailures: 3,
+		},
+		Shutdown: ShutdownConfig{
+			DrainTimeout: 30 * time.Second,
+			PushTimeout:  30 * time.Second,
+		},
+		Classification: ClassificationConfig{
+			Rules:   devices.DefaultRules(),
+			Default: devices.DefaultType,
+		},
+		Layout: LayoutConfig{
+			Template: storage.DefaultTemplate,
+		},
+		Normalization: NormalizationConfig{
+			Rules: normalize.DefaultRules(),
+		},
+		Redaction: RedactionConfig{
+			Mode: redact.ModePlaceholder,
+		},
+		Scanning: ScanningConfig{
+			Entropy: scan.DefaultEntropy(),
+		},
+	}
+}
+
+// Load reads the YAML file at path on top of the defaults. An empty path returns the defaults.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	if path == "" {
+		return cfg, nil
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Validate checks the configuration and reports every problem it finds.
+func (c *Config) Validate() error {
+	var errs []error
+	if _, _, err := net.SplitHostPort(c.Listen); err != nil {
+		errs = append(errs, fmt.Errorf("listen: invalid address %q: %w", c.Listen, err))
+	}
+	if c.TLS.Enabled() && (c.TLS.CertFile == "" || c.TLS.KeyFile == "") {
+		errs = append(errs, errors.New("tls.cert_file, tls.key_file: must be set together"))
+	}
+	if c.TLS.ClientCAFile != "" && !c.TLS.Enabled() {
+		errs = append(errs, errors.New("tls.client_ca_file: needs tls.cert_file and tls.key_file"))
+	}
+	if c.TLS.RequireClientCert && c.TLS.ClientCAFile == "" {
+		errs = append(errs, errors.New("tls.require_client_cert: needs tls.client_ca_file"))
+	}
+	switch c.Storage.Backend {
+	case BackendGit, BackendGoGit:
+		if c.Repository.URL == "" {
+			errs = append(errs, fmt.Errorf("repository.url: must be set for the %s backend", c.Storage.Backend))
+		}
+	case BackendFilesystem:
+	default:
+		errs = append(errs, fmt.Errorf("storage.backend: must be %q, %q or %q, got %q", BackendGit, BackendGoGit, BackendFilesystem, c.Storage.Backend))
+	}
+	if c.Storage.Backend == BackendGoGit && (c.Repository.AuthorName == "" || c.Repository.AuthorEmail == "") {
+		errs = append(errs, errors.New("repository.author_name, repository.author_email: must be set for the go-git backend"))
+	}
+	if c.Repository.Dir == "" {
+		errs = append(errs, errors.New("repository.dir: must be set"))
+	}
+	if c.Repository.Branch == "" {
+		errs = append(errs, errors.New("repository.branch: must be set"))
+	}
+	if c.Repository.PushInterval <= 0 {
+		errs = append(errs, fmt.Errorf("repository.push_interval: must be positive, got %s", c.Repository.PushInterval))
+	}
+	if c.Repository.DeprecationAge <= 0 {
+		errs = append(errs, fmt.Errorf("repository.deprecation_age: must be positive, got %s", c.Repository.DeprecationAge))
+	}
+	if c.Repository.BatchWindow < 0 {
+		errs = append(errs, fmt.Errorf("repository.batch_window: must not be negative, got %s", c.Repository.BatchWindow))
+	}
+	if c.Repository.BatchWindow > 0 {
+		if c.Storage.Backend != BackendGit {
+			errs = append(errs, fmt.Errorf("repository.batch_window: batching is only supported by the %s backend", BackendGit))
+		}
+		if c.Repository.BatchSize < 1 {
+			errs = append(errs, fmt.Errorf("repository.batch_size: must be at least 1, got %d", c.Repository.BatchSize))
+		}
+	}
+	if c.Spool.Dir == "" {
+		errs = append(errs, errors.New("spool.dir: must be set"))
+	} else if c.Repository.Dir != "" && isWithin(c.Spool.Dir, c.Repository.Dir) {
+		errs = append(errs, fmt.Errorf("spool.dir: %s must not be inside the repository directory", c.Spool.Dir))
+	}
+	if c.Spool.MaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("spool.max_attempts: must be at least 1, got %d", c.Spool.MaxAttempts))
+	}
+	if c.Health.MaxQueueDepth < 0 {
+		errs = append(errs, fmt.Errorf("health.max_queue_depth: must not be negative, got %d", c.Health.MaxQueueDepth))
+	}
+	if c.Health.MaxPushFailures < 0 {
+		errs = append(errs, fmt.Errorf("health.max_push_failures: must not be negative, got %d", c.Health.MaxPushFailures))
+	}
+	if c.Shutdown.DrainTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("shutdown.drain_timeout: must be positive, got %s", c.Shutdown.DrainTimeout))
+	}
+	if c.Shutdown.PushTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("shutdown.push_timeout: must be positive, got %s", c.Shutdown.PushTimeout))
+	}
+	if _, err := devices.NewClassifier(c.Classification.Rules, c.Classification.Default); err != nil {
+		errs = append(errs, fmt.Errorf("classification: %w", err))
+	}
+	if _, err := storage.NewLayout(c.Layout.Template, c.Layout.InventoryFile); err != nil {
+		errs = append(errs, fmt.Errorf("layout: %w", err))
+	}
+	if _, err := normalize.NewNormalizer(c.Normalization.Rules); err != nil {
+		errs = append(errs, fmt.Errorf("normalization: %w", err))
+	}
+	if _, err := redact.NewRedactor(c.Redaction.Rules); err != nil {
+		errs = append(errs, fmt.Errorf("redaction: %w", err))
+	} else if _, err := c.Redaction.Redactor(); err != nil {
+		errs = append(errs, fmt.Errorf("redaction: %w", err))
+	}
+	if _, err := c.Scanning.Scanner(); err != nil {
+		errs = append(errs, fmt.Errorf("scanning: %w", err))
+	}
+	if _, err := c.Encryption.Cipher(); err != nil {
+		errs = append(errs, fmt.Errorf("encryption: %w", err))
+	}
+	if _, err := auth.NewAuthenticator(c.Auth.Tokens, nil); err != nil {
+		errs = append(errs, fmt.Errorf("auth: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// isWithin reports whether path is dir or lies below it.
+func isWithin(path string, dir string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
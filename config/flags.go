@@ -0,0 +1,193 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// setting is a configuration value that can be overridden by a flag and an environment variable.
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	set   func(c *Config, value string) error
+}
+
+var settings = []setting{
+	{"listen", "VHS_LISTEN", "address to serve the API on", func(c *Config, v string) error {
+		c.Listen = v
+		return nil
+	}},
+	{"tls-cert-file", "VHS_TLS_CERT_FILE", "certificate to serve the API over TLS with", func(c *Config, v string) error {
+		c.TLS.CertFile = v
+		return nil
+	}},
+	{"tls-key-file", "VHS_TLS_KEY_FILE", "private key of the TLS certificate", func(c *Config, v string) error {
+		c.TLS.KeyFile = v
+		return nil
+	}},
+	{"tls-client-ca-file", "VHS_TLS_CLIENT_CA_FILE", "CA certificates client certificates are verified against", func(c *Config, v string) error {
+		c.TLS.ClientCAFile = v
+		return nil
+	}},
+	{"tls-require-client-cert", "VHS_TLS_REQUIRE_CLIENT_CERT", "reject clients without a verified certificate, true or false", func(c *Config, v string) error {
+		return setBool(&c.TLS.RequireClientCert, v)
+	}},
+	{"storage-backend", "VHS_STORAGE_BACKEND", "storage backend, git, go-git or filesystem", func(c *Config, v string) error {
+		c.Storage.Backend = v
+		return nil
+	}},
+	{"repo-url", "VHS_REPO_URL", "URL of the remote backup repository", func(c *Config, v string) error {
+		c.Repository.URL = v
+		return nil
+	}},
+	{"repo-dir", "VHS_REPO_DIR", "directory of the local clone", func(c *Config, v string) error {
+		c.Repository.Dir = v
+		return nil
+	}},
+	{"branch", "VHS_BRANCH", "branch backups are committed to", func(c *Config, v string) error {
+		c.Repository.Branch = v
+		return nil
+	}},
+	{"ssh-key-file", "VHS_SSH_KEY_FILE", "private key for ssh remotes of the go-git backend", func(c *Config, v string) error {
+		c.Repository.SSHKeyFile = v
+		return nil
+	}},
+	{"push-interval", "VHS_PUSH_INTERVAL", "how often to push to the remote", func(c *Config, v string) error {
+		return setDuration(&c.Repository.PushInterval, v)
+	}},
+	{"deprecation-age", "VHS_DEPRECATION_AGE", "age after which configurations are deprecated", func(c *Config, v string) error {
+		return setDuration(&c.Repository.DeprecationAge, v)
+	}},
+	{"batch-window", "VHS_BATCH_WINDOW", "how long to collect saves into one commit, 0 to disable", func(c *Config, v string) error {
+		return setDuration(&c.Repository.BatchWindow, v)
+	}},
+	{"batch-size", "VHS_BATCH_SIZE", "most saves to collect into one commit", func(c *Config, v string) error {
+		return setInt(&c.Repository.BatchSize, v)
+	}},
+	{"layout-template", "VHS_LAYOUT_TEMPLATE", "path template configurations are stored at, e.g. {type}/{hostname}", func(c *Config, v string) error {
+		c.Layout.Template = v
+		return nil
+	}},
+	{"layout-inventory-file", "VHS_LAYOUT_INVENTORY_FILE", "YAML file with the per device fields used by the layout template", func(c *Config, v string) error {
+		c.Layout.InventoryFile = v
+		return nil
+	}},
+	{"redaction-mode", "VHS_REDACTION_MODE", "what secrets are replaced by, placeholder or fingerprint", func(c *Config, v string) error {
+		c.Redaction.Mode = v
+		return nil
+	}},
+	{"redaction-fingerprint-key-file", "VHS_REDACTION_FINGERPRINT_KEY_FILE", "file holding the key secret fingerprints are computed with", func(c *Config, v string) error {
+		c.Redaction.FingerprintKeyFile = v
+		return nil
+	}},
+	{"encryption-identity-file", "VHS_ENCRYPTION_IDENTITY_FILE", "armored OpenPGP private key to decrypt stored backups with", func(c *Config, v string) error {
+		c.Encryption.IdentityFile = v
+		return nil
+	}},
+	{"auth-audit-log-file", "VHS_AUTH_AUDIT_LOG_FILE", "file rejected API requests are logged to, stderr by default", func(c *Config, v string) error {
+		c.Auth.AuditLogFile = v
+		return nil
+	}},
+	{"spool-dir", "VHS_SPOOL_DIR", "directory backups are spooled in before they are committed", func(c *Config, v string) error {
+		c.Spool.Dir = v
+		return nil
+	}},
+	{"spool-max-attempts", "VHS_SPOOL_MAX_ATTEMPTS", "commit attempts before a backup is dead-lettered", func(c *Config, v string) error {
+		return setInt(&c.Spool.MaxAttempts, v)
+	}},
+	{"health-max-queue-depth", "VHS_HEALTH_MAX_QUEUE_DEPTH", "spooled backups from which the server is not ready, 0 to disable", func(c *Config, v string) error {
+		return setInt(&c.Health.MaxQueueDepth, v)
+	}},
+	{"health-max-push-failures", "VHS_HEALTH_MAX_PUSH_FAILURES", "consecutive failed pushes from which the server is not ready, 0 to disable", func(c *Config, v string) error {
+		return setInt(&c.Health.MaxPushFailures, v)
+	}},
+	{"shutdown-drain-timeout", "VHS_SHUTDOWN_DRAIN_TIMEOUT", "how long spooled backups may take to be committed on shutdown", func(c *Config, v string) error {
+		return setDuration(&c.Shutdown.DrainTimeout, v)
+	}},
+	{"shutdown-push-timeout", "VHS_SHUTDOWN_PUSH_TIMEOUT", "how long the final push may take on shutdown", func(c *Config, v string) error {
+		return setDuration(&c.Shutdown.PushTimeout, v)
+	}},
+}
+
+// FromArgs builds the configuration from the file named by -config or VHS_CONFIG, then
+// applies environment variables and finally the remaining flags, and validates the result.
+func FromArgs(name string, args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	return Parse(flag.NewFlagSet(name, flag.ContinueOnError), args, lookupEnv)
+}
+
+// Parse works like FromArgs on a flag set the caller may have added flags of its own to.
+func Parse(fs *flag.FlagSet, args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	configPath, _ := lookupEnv("VHS_CONFIG")
+	fs.StringVar(&configPath, "config", configPath, "path to the YAML config file (env VHS_CONFIG)")
+	values := make(map[string]*string, len(settings))
+	for _, s := range settings {
+		values[s.flag] = fs.String(s.flag, "", fmt.Sprintf("%s (env %s)", s.usage, s.env))
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range settings {
+		if v, ok := lookupEnv(s.env); ok {
+			if err := s.set(cfg, v); err != nil {
+				return nil, fmt.Errorf("%s: %w", s.env, err)
+			}
+		}
+	}
+	for _, s := range settings {
+		if !isFlagSet(fs, s.flag) {
+			continue
+		}
+		if err := s.set(cfg, *values[s.flag]); err != nil {
+			return nil, fmt.Errorf("-%s: %w", s.flag, err)
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return cfg, nil
+}
+
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func setDuration(target *time.Duration, value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", value)
+	}
+	*target = d
+	return nil
+}
+
+func setBool(target *bool, value string) error {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("invalid boolean %q", value)
+	}
+	*target = b
+	return nil
+}
+
+func setInt(target *int, value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("invalid number %q", value)
+	}
+	*target = n
+	return nil
+}
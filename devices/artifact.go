@@ -0,0 +1,44 @@
+package devices
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Artifact is the output of one command run on a device, stored as its own file next to
+// the device's configuration.
+type Artifact struct {
+	// Name is the file name the artifact is stored under; see ArtifactFileName.
+	Name    string `json:"name"`
+	Payload []byte `json:"payload"`
+}
+
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// ArtifactFileName turns the name of an artifact, usually the command that produced it,
+// into a file name: runs of characters other than letters, digits, ".", "-" and "_" become
+// a single "_", so "show run | no-more" is stored as "show_run_no-more".
+func ArtifactFileName(name string) string {
+	return strings.Trim(unsafeFileNameChars.ReplaceAllString(strings.TrimSpace(name), "_"), "_")
+}
+
+// ValidateArtifacts checks that the artifacts of a device have valid, distinct file names.
+func ValidateArtifacts(artifacts []Artifact) error {
+	seen := make(map[string]bool, len(artifacts))
+	for _, artifact := range artifacts {
+		if err := ValidateName(artifact.Name); err != nil {
+			var invalid *InvalidNameError
+			if errors.As(err, &invalid) {
+				return fmt.Errorf("invalid artifact name %q: %s", artifact.Name, invalid.Reason)
+			}
+			return err
+		}
+		if seen[artifact.Name] {
+			return fmt.Errorf("artifact %q is sent more than once", artifact.Name)
+		}
+		seen[artifact.Name] = true
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package devices
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArtifactFileName(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"show run", "show_run"},
+		{"show interfaces | no-more", "show_interfaces_no-more"},
+		{" show version\n", "show_version"},
+		{"show_lldp.neighbors", "show_lldp.neighbors"},
+		{"../../etc/passwd", ".._.._etc_passwd"},
+		{"|", ""},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, ArtifactFileName(tc.input), "input: %q", tc.input)
+	}
+}
+
+func TestValidateArtifacts(t *testing.T) {
+	assert.NoError(t, ValidateArtifacts([]Artifact{{Name: "show_run"}, {Name: "show_version"}}))
+	assert.EqualError(t, ValidateArtifacts([]Artifact{{Name: ".._.._etc_passwd"}}), `invalid artifact name ".._.._etc_passwd": must not start with a dot`)
+	assert.EqualError(t, ValidateArtifacts([]Artifact{{Name: ""}}), `invalid artifact name "": must not be empty`)
+	assert.EqualError(t, ValidateArtifacts([]Artifact{{Name: "show_run"}, {Name: "show_run"}}), `artifact "show_run" is sent more than once`)
+}
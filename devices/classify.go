@@ -0,0 +1,145 @@
+package devices
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultType is the type of devices no rule matched, unless configured otherwise.
+const DefaultType = "Unknown"
+
+// Rule assigns a type to the devices it matches. Exactly one of Hostname, Inventory,
+// InventoryFile and Field is set.
+type Rule struct {
+	// Hostname is a regular expression matched against the device name. Type may refer to
+	// its capture groups, e.g. "$1" or "${site}".
+	Hostname string `yaml:"hostname,omitempty"`
+	// Inventory maps device names to types.
+	Inventory map[string]string `yaml:"inventory,omitempty"`
+	// InventoryFile is a YAML file mapping device names to types.
+	InventoryFile string `yaml:"inventory_file,omitempty"`
+	// Field names an attribute sent with the backup that holds the type.
+	Field string `yaml:"field,omitempty"`
+	// Type is the type of devices matched by Hostname.
+	Type string `yaml:"type,omitempty"`
+}
+
+// DefaultRules type devices by the first two characters of their name.
+func DefaultRules() []Rule {
+	return []Rule{
+		{Hostname: "(?i)^co", Type: "Core"},
+		{Hostname: "(?i)^la", Type: "Label"},
+	}
+}
+
+var defaultClassifier, _ = NewClassifier(DefaultRules(), DefaultType)
+
+// Classifier types devices by applying rules in order. The first rule yielding a valid
+// type wins; devices no rule matches get the fallback type.
+type Classifier struct {
+	rules    []rule
+	fallback string
+}
+
+type rule struct {
+	hostname  *regexp.Regexp
+	inventory map[string]string
+	field     string
+	typ       string
+}
+
+// NewClassifier compiles rules, loading inventory files, into a Classifier.
+func NewClassifier(rules []Rule, fallback string) (*Classifier, error) {
+	if !ValidType(fallback) {
+		return nil, fmt.Errorf("invalid fallback type %q", fallback)
+	}
+	c := &Classifier{fallback: fallback}
+	var errs []error
+	for i, r := range rules {
+		compiled, err := compileRule(r)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rule %d: %w", i+1, err))
+			continue
+		}
+		c.rules = append(c.rules, compiled)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func compileRule(r Rule) (rule, error) {
+	set := 0
+	for _, s := range []bool{r.Hostname != "", r.Inventory != nil, r.InventoryFile != "", r.Field != ""} {
+		if s {
+			set++
+		}
+	}
+	if set != 1 {
+		return rule{}, errors.New("exactly one of hostname, inventory, inventory_file and field must be set")
+	}
+	switch {
+	case r.Hostname != "":
+		if r.Type == "" {
+			return rule{}, errors.New("a hostname rule needs a type")
+		}
+		re, err := regexp.Compile(r.Hostname)
+		if err != nil {
+			return rule{}, fmt.Errorf("invalid hostname pattern: %w", err)
+		}
+		return rule{hostname: re, typ: r.Type}, nil
+	case r.InventoryFile != "":
+		content, err := ioutil.ReadFile(r.InventoryFile)
+		if err != nil {
+			return rule{}, fmt.Errorf("failed to read inventory: %w", err)
+		}
+		inventory := make(map[string]string)
+		if err := yaml.Unmarshal(content, &inventory); err != nil {
+			return rule{}, fmt.Errorf("failed to parse inventory %s: %w", r.InventoryFile, err)
+		}
+		return rule{inventory: inventory}, nil
+	case r.Inventory != nil:
+		return rule{inventory: r.Inventory}, nil
+	default:
+		return rule{field: r.Field}, nil
+	}
+}
+
+// Classify returns the type of the named device. attributes are the fields sent with the
+// device's backup, used by field rules.
+func (c *Classifier) Classify(name string, attributes map[string]string) string {
+	for _, r := range c.rules {
+		if t := r.apply(name, attributes); ValidType(t) {
+			return t
+		}
+	}
+	return c.fallback
+}
+
+func (r rule) apply(name string, attributes map[string]string) string {
+	switch {
+	case r.hostname != nil:
+		match := r.hostname.FindStringSubmatchIndex(name)
+		if match == nil {
+			return ""
+		}
+		return string(r.hostname.ExpandString(nil, r.typ, name, match))
+	case r.inventory != nil:
+		return r.inventory[name]
+	default:
+		return attributes[r.field]
+	}
+}
+
+// ValidType reports whether t can be used as a type, which becomes a folder name. It must
+// be a single path element and must not clash with the deprecated folder.
+func ValidType(t string) bool {
+	return t != "" && !strings.HasPrefix(t, ".") && !strings.ContainsAny(t, "/\\\x00") &&
+		!strings.EqualFold(t, "deprecated")
+}
@@ -0,0 +1,83 @@
+package devices
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassify(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "vhs-devices")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	inventoryFile := filepath.Join(tempDir, "inventory.yaml")
+	require.NoError(t, ioutil.WriteFile(inventoryFile, []byte("lab-router: Lab\n"), 0644))
+
+	c, err := NewClassifier([]Rule{
+		{Inventory: map[string]string{"core-99": "Legacy"}},
+		{InventoryFile: inventoryFile},
+		{Field: "role"},
+		{Hostname: `^(?P<site>[a-z]{3})\d-(?P<role>[a-z]+)\d+$`, Type: "${role}"},
+		{Hostname: "(?i)^co", Type: "Core"},
+	}, "Other")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		device     string
+		attributes map[string]string
+		expected   string
+	}{
+		{"Inventory wins over later rules", "core-99", map[string]string{"role": "Edge"}, "Legacy"},
+		{"Inventory file", "lab-router", nil, "Lab"},
+		{"Request field", "core-01", map[string]string{"role": "Edge"}, "Edge"},
+		{"Capture group", "ams1-spine01", nil, "spine"},
+		{"Prefix", "CORE-01", nil, "Core"},
+		{"Invalid field value falls through", "core-01", map[string]string{"role": "../etc"}, "Core"},
+		{"Reserved type falls through", "core-01", map[string]string{"role": "deprecated"}, "Core"},
+		{"Fallback", "x", nil, "Other"},
+		{"Empty name", "", nil, "Other"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, c.Classify(tc.device, tc.attributes))
+		})
+	}
+}
+
+func TestDefaultRules(t *testing.T) {
+	for name, expected := range map[string]string{"core-01": "Core", "Label-7": "Label", "edge-01": "Unknown", "c": "Unknown"} {
+		device := NewDevice(name, nil)
+		assert.Equal(t, expected, device.GetDeviceType(), name)
+	}
+	device := NewDevice("core-01", nil)
+	device.Type = "Edge"
+	assert.Equal(t, "Edge", device.GetDeviceType())
+}
+
+func TestNewClassifierErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rules    []Rule
+		fallback string
+		contains string
+	}{
+		{"No matcher", []Rule{{Type: "Core"}}, "Unknown", "rule 1: exactly one of"},
+		{"Two matchers", []Rule{{Hostname: "^co", Field: "role", Type: "Core"}}, "Unknown", "rule 1: exactly one of"},
+		{"Hostname without type", []Rule{{Hostname: "^co"}}, "Unknown", "needs a type"},
+		{"Invalid pattern", []Rule{{Hostname: "(", Type: "Core"}}, "Unknown", "invalid hostname pattern"},
+		{"Missing inventory file", []Rule{{InventoryFile: "/does/not/exist.yaml"}}, "Unknown", "failed to read inventory"},
+		{"Invalid fallback", nil, "a/b", "invalid fallback type"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewClassifier(tc.rules, tc.fallback)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.contains)
+		})
+	}
+}
@@ -1,15 +1,21 @@
 package devices
 
-import (
-	"strings"
-)
-
 type Device struct {
 	Name    string
 	Payload []byte
+	// Type is the folder the device's configuration is stored in. It is set when the
+	// device is classified; see GetDeviceType.
+	Type string
+	// Attributes are free-form fields sent with the backup, e.g. the device's site.
+	Attributes map[string]string
+	// Metadata describes the device, it is stored alongside the configuration.
+	Metadata Metadata
+	// Artifacts are outputs of further commands, e.g. "show version", that are versioned
+	// separately from the configuration.
+	Artifacts []Artifact
 }
 
-// NewDevice creates a new Device and determines its type based on the first two characters of its name.
+// NewDevice creates a new Device. Its type is left to classification.
 func NewDevice(name string, payload []byte) Device {
 	return Device{
 		Name:    name,
@@ -17,15 +23,20 @@ func NewDevice(name string, payload []byte) Device {
 	}
 }
 
-// GetDeviceType determines the device type based on the first two characters of the device name.
+// Validate checks that the device's name and the names of its artifacts are safe to use
+// as file names.
+func (d *Device) Validate() error {
+	if err := ValidateName(d.Name); err != nil {
+		return err
+	}
+	return ValidateArtifacts(d.Artifacts)
+}
+
+// GetDeviceType returns the device's type. Devices that were not classified are typed by
+// the DefaultRules, which look at the first two characters of the device name.
 func (d *Device) GetDeviceType() string {
-	switch strings.ToLower(d.Name[:2]) {
-	case "co":
-		return "Core"
-	case "la":
-		return "Label"
-	// Add more cases as needed.
-	default:
-		return "Unknown"
+	if d.Type != "" {
+		return d.Type
 	}
+	return defaultClassifier.Classify(d.Name, nil)
 }
@@ -0,0 +1,60 @@
+package devices
+
+import "time"
+
+// Metadata describes a device beyond its name, as reported by the collector that backed
+// it up.
+type Metadata struct {
+	Vendor    string   `json:"vendor,omitempty" yaml:"vendor,omitempty"`
+	OS        string   `json:"os,omitempty" yaml:"os,omitempty"`
+	OSVersion string   `json:"os_version,omitempty" yaml:"os_version,omitempty"`
+	Model     string   `json:"model,omitempty" yaml:"model,omitempty"`
+	Serial    string   `json:"serial,omitempty" yaml:"serial,omitempty"`
+	Site      string   `json:"site,omitempty" yaml:"site,omitempty"`
+	Role      string   `json:"role,omitempty" yaml:"role,omitempty"`
+	Tags      []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// CollectedAt is when the configuration was taken from the device.
+	CollectedAt time.Time `json:"collected_at,omitempty" yaml:"collected_at,omitempty"`
+	// Collector identifies what took the backup.
+	Collector string `json:"collector,omitempty" yaml:"collector,omitempty"`
+}
+
+// IsZero reports whether no metadata is set.
+func (m Metadata) IsZero() bool {
+	return m.Vendor == "" && m.OS == "" && m.OSVersion == "" && m.Model == "" && m.Serial == "" &&
+		m.Site == "" && m.Role == "" && len(m.Tags) == 0 && m.CollectedAt.IsZero() && m.Collector == ""
+}
+
+// Field returns the metadata field of the given name, as used in layout templates, and
+// whether it is a metadata field at all.
+func (m Metadata) Field(name string) (string, bool) {
+	switch name {
+	case "vendor":
+		return m.Vendor, true
+	case "os":
+		return m.OS, true
+	case "os_version":
+		return m.OSVersion, true
+	case "model":
+		return m.Model, true
+	case "serial":
+		return m.Serial, true
+	case "site":
+		return m.Site, true
+	case "role":
+		return m.Role, true
+	case "collector":
+		return m.Collector, true
+	}
+	return "", false
+}
+
+// HasTag reports whether the device is tagged with tag.
+func (m Metadata) HasTag(tag string) bool {
+	for _, t := range m.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package devices
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxNameLength is the longest device name accepted, the longest DNS name.
+const MaxNameLength = 253
+
+// ErrInvalidName is wrapped by every InvalidNameError.
+var ErrInvalidName = errors.New("invalid device name")
+
+// InvalidNameError explains why a device name was rejected.
+type InvalidNameError struct {
+	Name   string
+	Reason string
+}
+
+func (e *InvalidNameError) Error() string {
+	return fmt.Sprintf("%s %q: %s", ErrInvalidName, e.Name, e.Reason)
+}
+
+func (e *InvalidNameError) Unwrap() error {
+	return ErrInvalidName
+}
+
+// NormalizeName trims surrounding white space from a device name and checks the result
+// with ValidateName.
+func NormalizeName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	return name, ValidateName(name)
+}
+
+// ValidateName checks that a device name is safe to use as a file name. The name ends up
+// in a path below the repository, so it must not be able to add folders, escape the
+// repository or clash with the files VHS and git keep there.
+func ValidateName(name string) error {
+	reason := ""
+	switch {
+	case name == "":
+		reason = "must not be empty"
+	case len(name) > MaxNameLength:
+		reason = fmt.Sprintf("must not be longer than %d bytes", MaxNameLength)
+	case !utf8.ValidString(name):
+		reason = "must be valid UTF-8"
+	case strings.HasPrefix(name, "."):
+		reason = "must not start with a dot"
+	case strings.ContainsAny(name, `/\`):
+		reason = "must not contain path separators"
+	case strings.IndexFunc(name, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) >= 0:
+		reason = "must not contain white space or control characters"
+	case strings.EqualFold(name, "deprecated"):
+		reason = "is reserved"
+	default:
+		return nil
+	}
+	return &InvalidNameError{Name: name, Reason: reason}
+}
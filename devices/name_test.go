@@ -0,0 +1,55 @@
+package devices
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+		reason   string
+	}{
+		{name: "Plain hostname", input: "core-01", expected: "core-01"},
+		{name: "Fully qualified name", input: "core-01.ams1.example.net", expected: "core-01.ams1.example.net"},
+		{name: "Surrounding white space", input: " core-01\n", expected: "core-01"},
+		{name: "Longest name", input: strings.Repeat("a", MaxNameLength), expected: strings.Repeat("a", MaxNameLength)},
+		{name: "Empty", input: "", reason: "must not be empty"},
+		{name: "Only white space", input: " \t", reason: "must not be empty"},
+		{name: "Too long", input: strings.Repeat("a", MaxNameLength+1), reason: "must not be longer than 253 bytes"},
+		{name: "Parent directory", input: "..", reason: "must not start with a dot"},
+		{name: "Current directory", input: ".", reason: "must not start with a dot"},
+		{name: "Traversal", input: "../../etc/x", reason: "must not start with a dot"},
+		{name: "Git directory", input: ".git", reason: "must not start with a dot"},
+		{name: "Absolute path", input: "/etc/passwd", reason: "must not contain path separators"},
+		{name: "Nested path", input: "core/01", reason: "must not contain path separators"},
+		{name: "Traversal after a prefix", input: "core-01/../../x", reason: "must not contain path separators"},
+		{name: "Windows separator", input: `..\x`, reason: "must not start with a dot"},
+		{name: "Windows path", input: `core\01`, reason: "must not contain path separators"},
+		{name: "NUL byte", input: "core\x0001", reason: "must not contain white space or control characters"},
+		{name: "Newline", input: "core\n01", reason: "must not contain white space or control characters"},
+		{name: "Inner space", input: "core 01", reason: "must not contain white space or control characters"},
+		{name: "Invalid UTF-8", input: "core\xff", reason: "must be valid UTF-8"},
+		{name: "Deprecated folder", input: "Deprecated", reason: "is reserved"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			name, err := NormalizeName(tc.input)
+			if tc.reason == "" {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, name)
+				return
+			}
+			require.Error(t, err)
+			assert.ErrorIs(t, err, ErrInvalidName)
+			var invalid *InvalidNameError
+			require.ErrorAs(t, err, &invalid)
+			assert.Equal(t, tc.reason, invalid.Reason)
+		})
+	}
+}
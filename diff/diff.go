@@ -0,0 +1,238 @@
+// Package diff produces line based unified diffs of device configurations.
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// op is one step of an edit script turning a into b.
+type op struct {
+	kind opKind
+	a, b int // line indexes in a and b
+}
+
+// Unified returns the unified diff between from and to with the given number of context lines.
+// The result is empty when both inputs contain the same lines.
+func Unified(fromName, toName string, from, to []byte, context int) string {
+	a, b := splitLines(from), splitLines(to)
+	ops := editScript(a, b)
+	hunks := groupHunks(ops, context)
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	for _, hunk := range hunks {
+		aStart, aCount, bStart, bCount := hunkRange(hunk)
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", formatRange(aStart, aCount), formatRange(bStart, bCount))
+		for _, o := range hunk {
+			switch o.kind {
+			case opEqual:
+				out.WriteString(" " + a[o.a] + "\n")
+			case opDelete:
+				out.WriteString("-" + a[o.a] + "\n")
+			case opInsert:
+				out.WriteString("+" + b[o.b] + "\n")
+			}
+		}
+	}
+	return out.String()
+}
+
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	content = bytes.TrimSuffix(content, []byte("\n"))
+	return strings.Split(string(content), "\n")
+}
+
+// maxCost bounds the edit distance searched for between two blocks of lines. Blocks that
+// are further apart are diffed as one replacement, which keeps huge rewrites from taking
+// quadratic time; the diff is still correct, just not the shortest one.
+const maxCost = 1000
+
+// editScript computes an edit script with the linear space variant of Myers' algorithm,
+// which splits the problem at the middle snake of a shortest edit script.
+func editScript(a, b []string) []op {
+	var ops []op
+	diffRange(a, b, 0, len(a), 0, len(b), &ops)
+	return ops
+}
+
+// diffRange appends the edit script turning a[a0:a1] into b[b0:b1] to ops.
+func diffRange(a, b []string, a0, a1, b0, b1 int, ops *[]op) {
+	for a0 < a1 && b0 < b1 && a[a0] == b[b0] {
+		*ops = append(*ops, op{kind: opEqual, a: a0, b: b0})
+		a0++
+		b0++
+	}
+	suffix := 0
+	for a1-suffix > a0 && b1-suffix > b0 && a[a1-suffix-1] == b[b1-suffix-1] {
+		suffix++
+	}
+	a1, b1 = a1-suffix, b1-suffix
+
+	if x, y, ok := middleSnake(a, b, a0, a1, b0, b1); ok {
+		diffRange(a, b, a0, x, b0, y, ops)
+		diffRange(a, b, x, a1, y, b1, ops)
+	} else {
+		for i := a0; i < a1; i++ {
+			*ops = append(*ops, op{kind: opDelete, a: i, b: b0})
+		}
+		for j := b0; j < b1; j++ {
+			*ops = append(*ops, op{kind: opInsert, a: a1, b: j})
+		}
+	}
+	for i := 0; i < suffix; i++ {
+		*ops = append(*ops, op{kind: opEqual, a: a1 + i, b: b1 + i})
+	}
+}
+
+// middleSnake searches a shortest edit script turning a[a0:a1] into b[b0:b1] from both
+// ends at once and returns the point where the searches overlap, which splits the problem
+// into two smaller ones. It reports false when the blocks have nothing in common, when one
+// of them is empty, or when they are more than maxCost edits apart.
+func middleSnake(a, b []string, a0, a1, b0, b1 int) (int, int, bool) {
+	n, m := a1-a0, b1-b0
+	if n == 0 || m == 0 {
+		return 0, 0, false
+	}
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	// forward[offset+k] and backward[offset+k] hold the furthest x reached on diagonal k
+	// from the start and from the end, -1 while it was not reached.
+	forward := make([]int, 2*maxD+2)
+	backward := make([]int, 2*maxD+2)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+	delta := n - m
+	// With an odd delta the searches overlap while extending forward, otherwise backward.
+	odd := delta%2 != 0
+	// Diagonals that ran off the edge of the grid are not extended any further.
+	var kStart, kEnd, rStart, rEnd int
+	for d := 0; d < maxD && d <= maxCost; d++ {
+		for k := -d + kStart; k <= d-kEnd; k += 2 {
+			var x int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[a0+x] == b[b0+y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			switch {
+			case x > n:
+				kEnd += 2
+			case y > m:
+				kStart += 2
+			case odd:
+				if r := offset + delta - k; r >= 0 && r < len(backward) && backward[r] != -1 && x >= n-backward[r] {
+					return a0 + x, b0 + y, true
+				}
+			}
+		}
+		for k := -d + rStart; k <= d-rEnd; k += 2 {
+			var x int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[a1-x-1] == b[b1-y-1] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+			switch {
+			case x > n:
+				rEnd += 2
+			case y > m:
+				rStart += 2
+			case !odd:
+				if f := offset + delta - k; f >= 0 && f < len(forward) && forward[f] != -1 && forward[f] >= n-x {
+					fx := forward[f]
+					return a0 + fx, b0 + fx - (f - offset), true
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// groupHunks splits an edit script into hunks of changes surrounded by up to context equal lines.
+func groupHunks(ops []op, context int) [][]op {
+	var hunks [][]op
+	start := -1 // first op of the current hunk
+	end := -1   // last change of the current hunk
+	for i, o := range ops {
+		if o.kind == opEqual {
+			continue
+		}
+		if start >= 0 && i-end-1 > 2*context {
+			hunks = append(hunks, ops[start:hunkEnd(end, context, len(ops))])
+			start = -1
+		}
+		if start < 0 {
+			start = i - context
+			if start < 0 {
+				start = 0
+			}
+		}
+		end = i
+	}
+	if start >= 0 {
+		hunks = append(hunks, ops[start:hunkEnd(end, context, len(ops))])
+	}
+	return hunks
+}
+
+// hunkEnd returns the exclusive end of a hunk whose last change is at index end.
+func hunkEnd(end, context, length int) int {
+	if end+context+1 > length {
+		return length
+	}
+	return end + context + 1
+}
+
+func hunkRange(hunk []op) (aStart, aCount, bStart, bCount int) {
+	aStart, bStart = hunk[0].a, hunk[0].b
+	for _, o := range hunk {
+		if o.kind != opInsert {
+			aCount++
+		}
+		if o.kind != opDelete {
+			bCount++
+		}
+	}
+	return aStart, aCount, bStart, bCount
+}
+
+// formatRange renders a hunk range the way GNU diff does.
+func formatRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
@@ -0,0 +1,145 @@
+package diff
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnified(t *testing.T) {
+	testCases := []struct {
+		name     string
+		from, to string
+		context  int
+		expected string
+	}{
+		{
+			name:     "Identical",
+			from:     "a\nb\nc\n",
+			to:       "a\nb\nc\n",
+			context:  3,
+			expected: "",
+		},
+		{
+			name:     "Changed line",
+			from:     "a\nb\nc\n",
+			to:       "a\nB\nc\n",
+			context:  3,
+			expected: "--- from\n+++ to\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:     "Added to empty",
+			from:     "",
+			to:       "a\nb\n",
+			context:  3,
+			expected: "--- from\n+++ to\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:     "Removed everything",
+			from:     "a\n",
+			to:       "",
+			context:  3,
+			expected: "--- from\n+++ to\n@@ -1 +0,0 @@\n-a\n",
+		},
+		{
+			name:     "Separate hunks",
+			from:     "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			to:       "1\nx\n3\n4\n5\n6\n7\ny\n9\n",
+			context:  1,
+			expected: "--- from\n+++ to\n@@ -1,3 +1,3 @@\n 1\n-2\n+x\n 3\n@@ -7,3 +7,3 @@\n 7\n-8\n+y\n 9\n",
+		},
+		{
+			name:     "Merged hunks",
+			from:     "1\n2\n3\n4\n5\n",
+			to:       "1\nx\n3\ny\n5\n",
+			context:  1,
+			expected: "--- from\n+++ to\n@@ -1,5 +1,5 @@\n 1\n-2\n+x\n 3\n-4\n+y\n 5\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := Unified("from", "to", []byte(tc.from), []byte(tc.to), tc.context)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+// checkScript checks that ops turns a into b and returns the number of edits it makes.
+func checkScript(t *testing.T, a, b []string, ops []op) int {
+	gotA, gotB := []string{}, []string{}
+	edits := 0
+	for _, o := range ops {
+		switch o.kind {
+		case opEqual:
+			require.Equal(t, a[o.a], b[o.b])
+			gotA, gotB = append(gotA, a[o.a]), append(gotB, b[o.b])
+		case opDelete:
+			gotA = append(gotA, a[o.a])
+			edits++
+		case opInsert:
+			gotB = append(gotB, b[o.b])
+			edits++
+		}
+	}
+	require.Equal(t, append([]string{}, a...), gotA)
+	require.Equal(t, append([]string{}, b...), gotB)
+	return edits
+}
+
+func TestEditScriptIsShortest(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	lines := func() []string {
+		out := make([]string, random.Intn(12))
+		for i := range out {
+			out[i] = string(rune('a' + random.Intn(3)))
+		}
+		return out
+	}
+	for i := 0; i < 500; i++ {
+		a, b := lines(), lines()
+		// The shortest edit script keeps the longest common subsequence.
+		lcs := make([][]int, len(a)+1)
+		for x := range lcs {
+			lcs[x] = make([]int, len(b)+1)
+		}
+		for x := len(a) - 1; x >= 0; x-- {
+			for y := len(b) - 1; y >= 0; y-- {
+				if a[x] == b[y] {
+					lcs[x][y] = lcs[x+1][y+1] + 1
+				} else if lcs[x+1][y] > lcs[x][y+1] {
+					lcs[x][y] = lcs[x+1][y]
+				} else {
+					lcs[x][y] = lcs[x][y+1]
+				}
+			}
+		}
+		edits := checkScript(t, a, b, editScript(a, b))
+		assert.Equal(t, len(a)+len(b)-2*lcs[0][0], edits, "%q to %q", a, b)
+	}
+}
+
+func TestUnifiedLargeRewrite(t *testing.T) {
+	var from, to strings.Builder
+	for i := 0; i < 50000; i++ {
+		fmt.Fprintf(&from, "old line %d\n", i)
+		fmt.Fprintf(&to, "new line %d\n", i)
+	}
+	started := time.Now()
+	actual := Unified("from", "to", []byte(from.String()), []byte(to.String()), 3)
+	assert.Less(t, time.Since(started), 10*time.Second)
+	assert.True(t, strings.HasPrefix(actual, "--- from\n+++ to\n@@ -1,50000 +1,50000 @@\n-old line 0\n"))
+	assert.Equal(t, 100000+3, strings.Count(actual, "\n"))
+
+	// Far apart blocks are replaced as a whole, the script stays correct.
+	a, b := splitLines([]byte(from.String())), splitLines([]byte(to.String()))
+	for i := 0; i < len(b); i += 10 {
+		b[i] = a[i]
+	}
+	checkScript(t, a, b, editScript(a, b))
+}
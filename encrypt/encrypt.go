@@ -0,0 +1,139 @@
+// Package encrypt encrypts stored configurations to OpenPGP recipients, so the repository
+// remote only ever sees ciphertext. Content is ASCII armored to keep the stored files text.
+package encrypt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+// messageType is the armor block type of an encrypted OpenPGP message.
+const messageType = "PGP MESSAGE"
+
+var armorHeader = []byte("-----BEGIN " + messageType + "-----")
+
+// ErrNoIdentity is returned when encrypted content is read by a Cipher without an identity.
+var ErrNoIdentity = errors.New("no identity to decrypt the stored content with")
+
+// Cipher encrypts content to a set of recipients and decrypts it with an identity.
+type Cipher struct {
+	recipients openpgp.EntityList
+	identity   openpgp.EntityList
+}
+
+// NewCipher creates a Cipher encrypting to recipients. identity holds the private key of
+// one of them; without it the Cipher can only encrypt.
+func NewCipher(recipients openpgp.EntityList, identity openpgp.EntityList) (*Cipher, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("at least one recipient must be given")
+	}
+	for _, entity := range identity {
+		if entity.PrivateKey == nil {
+			return nil, fmt.Errorf("identity %s has no private key", entity.PrimaryKey.KeyIdString())
+		}
+		if entity.PrivateKey.Encrypted {
+			return nil, fmt.Errorf("identity %s is protected by a passphrase", entity.PrimaryKey.KeyIdString())
+		}
+	}
+	return &Cipher{recipients: recipients, identity: identity}, nil
+}
+
+// Load reads armored public keys from recipientFiles and, unless identityFile is empty,
+// the armored private key to decrypt with from identityFile.
+func Load(recipientFiles []string, identityFile string) (*Cipher, error) {
+	var recipients openpgp.EntityList
+	for _, file := range recipientFiles {
+		keys, err := readKeyRing(file)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, keys...)
+	}
+	var identity openpgp.EntityList
+	if identityFile != "" {
+		keys, err := readKeyRing(identityFile)
+		if err != nil {
+			return nil, err
+		}
+		identity = keys
+	}
+	return NewCipher(recipients, identity)
+}
+
+func readKeyRing(file string) (openpgp.EntityList, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	keys, err := openpgp.ReadArmoredKeyRing(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keys from %s: %w", file, err)
+	}
+	return keys, nil
+}
+
+// Encrypt encrypts plaintext to every recipient.
+func (c *Cipher) Encrypt(plaintext []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	armored, err := armor.Encode(&buf, messageType, nil)
+	if err != nil {
+		return nil, err
+	}
+	w, err := openpgp.Encrypt(armored, c.recipients, nil, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt: %w", err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, fmt.Errorf("failed to encrypt: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encrypt: %w", err)
+	}
+	if err := armored.Close(); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// Decrypt decrypts content written by Encrypt. Content that is not encrypted, such as
+// revisions stored before encryption was turned on, is returned unchanged.
+func (c *Cipher) Decrypt(content []byte) ([]byte, error) {
+	if !IsEncrypted(content) {
+		return content, nil
+	}
+	if len(c.identity) == 0 {
+		return nil, ErrNoIdentity
+	}
+	block, err := armor.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	md, err := openpgp.ReadMessage(block.Body, c.identity, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	plaintext, err := ioutil.ReadAll(md.UnverifiedBody)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return plaintext, nil
+}
+
+// CanDecrypt reports whether the Cipher has an identity to decrypt with.
+func (c *Cipher) CanDecrypt() bool {
+	return len(c.identity) > 0
+}
+
+// IsEncrypted reports whether content is an armored OpenPGP message.
+func IsEncrypted(content []byte) bool {
+	return bytes.HasPrefix(content, armorHeader)
+}
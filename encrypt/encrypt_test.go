@@ -0,0 +1,98 @@
+package encrypt
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEntity(t *testing.T, name string) *openpgp.Entity {
+	t.Helper()
+	entity, err := openpgp.NewEntity(name, "", name+"@example.com", nil)
+	require.NoError(t, err)
+	return entity
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	ops, backup := newEntity(t, "ops"), newEntity(t, "backup")
+	c, err := NewCipher(openpgp.EntityList{ops, backup}, openpgp.EntityList{backup})
+	require.NoError(t, err)
+
+	encrypted, err := c.Encrypt([]byte("hostname core-01\n"))
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(encrypted))
+	assert.NotContains(t, string(encrypted), "core-01")
+	again, err := c.Encrypt([]byte("hostname core-01\n"))
+	require.NoError(t, err)
+	assert.NotEqual(t, encrypted, again, "encryption is randomized")
+
+	plaintext, err := c.Decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "hostname core-01\n", string(plaintext))
+	plaintext, err = c.Decrypt([]byte("hostname core-02\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "hostname core-02\n", string(plaintext), "plain content is returned unchanged")
+
+	// Every recipient can decrypt.
+	other, err := NewCipher(openpgp.EntityList{ops}, openpgp.EntityList{ops})
+	require.NoError(t, err)
+	plaintext, err = other.Decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "hostname core-01\n", string(plaintext))
+
+	encryptOnly, err := NewCipher(openpgp.EntityList{ops}, nil)
+	require.NoError(t, err)
+	assert.False(t, encryptOnly.CanDecrypt())
+	_, err = encryptOnly.Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrNoIdentity)
+
+	stranger, err := NewCipher(openpgp.EntityList{ops}, openpgp.EntityList{newEntity(t, "stranger")})
+	require.NoError(t, err)
+	_, err = stranger.Decrypt(encrypted)
+	assert.Error(t, err)
+}
+
+func TestLoad(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "vhs-encrypt")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	entity := newEntity(t, "backup")
+	publicFile := filepath.Join(tempDir, "backup.asc")
+	privateFile := filepath.Join(tempDir, "backup.key")
+	writeKey(t, publicFile, openpgp.PublicKeyType, entity.Serialize)
+	writeKey(t, privateFile, openpgp.PrivateKeyType, func(w io.Writer) error { return entity.SerializePrivate(w, nil) })
+
+	c, err := Load([]string{publicFile}, privateFile)
+	require.NoError(t, err)
+	assert.True(t, c.CanDecrypt())
+	encrypted, err := c.Encrypt([]byte("hostname core-01\n"))
+	require.NoError(t, err)
+	plaintext, err := c.Decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "hostname core-01\n", string(plaintext))
+
+	_, err = Load([]string{filepath.Join(tempDir, "missing.asc")}, "")
+	assert.Error(t, err)
+	_, err = Load(nil, privateFile)
+	assert.EqualError(t, err, "at least one recipient must be given")
+	_, err = Load([]string{publicFile}, publicFile)
+	assert.ErrorContains(t, err, "has no private key")
+}
+
+func writeKey(t *testing.T, path string, blockType string, serialize func(io.Writer) error) {
+	t.Helper()
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	w, err := armor.Encode(f, blockType, nil)
+	require.NoError(t, err)
+	require.NoError(t, serialize(w))
+	require.NoError(t, w.Close())
+}
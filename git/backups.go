@@ -0,0 +1,169 @@
+package git
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+	"vhs/storage"
+)
+
+// List returns every device with a configuration in the working tree, sorted by name.
+// Deprecated configurations are not included.
+func (g *Git) List() ([]storage.DeviceInfo, error) {
+	infos, err := g.Layout.List(g.RepoDir)
+	if err != nil {
+		return nil, err
+	}
+	g.seen.Update(infos)
+	return infos, nil
+}
+
+// Get returns the revision of a device selected by query.
+func (g *Git) Get(name string, query storage.Query) (storage.Revision, error) {
+	switch {
+	case query.Previous:
+		commit := query.Revision
+		if commit == "" {
+			latest, err := g.GetLatestBackup(name)
+			if err != nil {
+				return storage.Revision{}, err
+			}
+			commit = latest.Commit
+		}
+		return g.GetPreviousBackup(name, commit)
+	case query.Revision != "":
+		return g.GetBackupAtCommit(name, query.Revision)
+	case !query.At.IsZero():
+		return g.GetBackupAt(name, query.At)
+	default:
+		return g.GetLatestBackup(name)
+	}
+}
+
+// Diff returns a unified diff of a device's configuration between two commits.
+func (g *Git) Diff(name string, from string, to string) (string, error) {
+	return storage.DiffRevisions(g, name, from, to)
+}
+
+// GetLatestBackup returns the most recently committed configuration of a device.
+func (g *Git) GetLatestBackup(name string) (storage.Revision, error) {
+	return g.findRevision(name)
+}
+
+// GetBackupAt returns the newest configuration of a device committed at or before t.
+func (g *Git) GetBackupAt(name string, t time.Time) (storage.Revision, error) {
+	return g.findRevision(name, "--before="+t.Format(time.RFC3339))
+}
+
+// GetBackupAtCommit returns the configuration of a device as stored in the given commit.
+func (g *Git) GetBackupAtCommit(name string, commit string) (storage.Revision, error) {
+	output, err := g.runGitCommand("rev-parse", "--verify", "--quiet", commit+"^{commit}")
+	if err != nil {
+		return storage.Revision{}, fmt.Errorf("%w: unknown commit %s", storage.ErrNotFound, commit)
+	}
+	return g.readRevision(name, strings.TrimSpace(string(output)))
+}
+
+// GetPreviousBackup returns the configuration of a device as it was before the given commit.
+func (g *Git) GetPreviousBackup(name string, commit string) (storage.Revision, error) {
+	output, err := g.runGitCommand("rev-parse", "--verify", "--quiet", commit+"^{commit}^")
+	if err != nil {
+		return storage.Revision{}, fmt.Errorf("%w: no backup of %s before commit %s", storage.ErrNotFound, name, commit)
+	}
+	parent := strings.TrimSpace(string(output))
+	// Follow the file from where it was in the parent, it may have moved since.
+	path, err := g.pathAt(name, parent)
+	if err != nil {
+		return storage.Revision{}, err
+	}
+	return g.findRevisionOf(name, path, parent)
+}
+
+// findRevision resolves the newest commit touching a device's file, following it across
+// moves and narrowed by extra git log arguments.
+func (g *Git) findRevision(name string, args ...string) (storage.Revision, error) {
+	if !g.hasCommits() {
+		return storage.Revision{}, storage.ErrNotFound
+	}
+	return g.findRevisionOf(name, g.devicePath(name), args...)
+}
+
+func (g *Git) findRevisionOf(name string, path string, args ...string) (storage.Revision, error) {
+	args = append([]string{"log", "-1", "--follow", "--format=%H", "--name-only"}, args...)
+	args = append(args, "--", path)
+	output, err := g.runGitCommand(args...)
+	if err != nil {
+		return storage.Revision{}, err
+	}
+	// The commit is followed by the path of the file in it.
+	lines := nonEmptyLines(output)
+	if len(lines) < 2 {
+		return storage.Revision{}, storage.ErrNotFound
+	}
+	return g.readRevisionAt(name, lines[0], lines[1])
+}
+
+// readRevision reads a device's file from a commit.
+func (g *Git) readRevision(name string, commit string) (storage.Revision, error) {
+	path, err := g.pathAt(name, commit)
+	if err != nil {
+		return storage.Revision{}, err
+	}
+	return g.readRevisionAt(name, commit, path)
+}
+
+// readRevisionAt reads a device's file from a commit, where it is stored at path.
+func (g *Git) readRevisionAt(name string, commit string, path string) (storage.Revision, error) {
+	output, err := g.runGitCommand("show", commit+":"+filepath.ToSlash(path))
+	if err != nil {
+		return storage.Revision{}, fmt.Errorf("%w: device %s not present in commit %s", storage.ErrNotFound, name, commit)
+	}
+	timestamp, payload := storage.SplitHeader(output)
+	return storage.Revision{
+		Device:    name,
+		Commit:    commit,
+		Timestamp: timestamp,
+		Payload:   payload,
+	}, nil
+}
+
+// pathAt returns where a device's file is stored in a commit. Files that were moved, e.g.
+// by a layout migration, are found at their old path in older commits.
+func (g *Git) pathAt(name string, commit string) (string, error) {
+	current := g.devicePath(name)
+	if g.exists(commit, current) {
+		return current, nil
+	}
+	output, err := g.runGitCommand("log", "--follow", "--name-only", "--format=", "--", current)
+	if err == nil {
+		for _, path := range nonEmptyLines(output) {
+			if path != filepath.ToSlash(current) && g.exists(commit, path) {
+				return filepath.FromSlash(path), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%w: device %s not present in commit %s", storage.ErrNotFound, name, commit)
+}
+
+// exists reports whether a commit holds a file at path.
+func (g *Git) exists(commit string, path string) bool {
+	_, err := g.runGitCommand("cat-file", "-e", commit+":"+filepath.ToSlash(path))
+	return err == nil
+}
+
+func nonEmptyLines(output []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// hasCommits reports whether the current branch has at least one commit.
+func (g *Git) hasCommits() bool {
+	_, err := g.runGitCommand("rev-parse", "--verify", "--quiet", "HEAD")
+	return err == nil
+}
@@ -0,0 +1,90 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+	"vhs/devices"
+	"vhs/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBackups(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "vhs-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	g := NewGit(tempDir, "main")
+	_, err = g.GetLatestBackup("core-01")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	_, err = g.SaveDeviceConfiguration(devices.NewDevice("core-01", []byte("hostname core-01\nversion 1")))
+	require.NoError(t, err)
+	first, err := g.GetLatestBackup("core-01")
+	require.NoError(t, err)
+	assert.Equal(t, "hostname core-01\nversion 1", string(first.Payload))
+	assert.NotEmpty(t, first.Commit)
+	assert.False(t, first.Timestamp.IsZero())
+
+	_, err = g.SaveDeviceConfiguration(devices.NewDevice("core-01", []byte("hostname core-01\nversion 2")))
+	require.NoError(t, err)
+	_, err = g.SaveDeviceConfiguration(devices.NewDevice("label-01", []byte("hostname label-01")))
+	require.NoError(t, err)
+
+	latest, err := g.GetLatestBackup("core-01")
+	require.NoError(t, err)
+	assert.Equal(t, "hostname core-01\nversion 2", string(latest.Payload))
+	assert.NotEqual(t, first.Commit, latest.Commit)
+
+	atCommit, err := g.GetBackupAtCommit("core-01", first.Commit[:10])
+	require.NoError(t, err)
+	assert.Equal(t, first.Commit, atCommit.Commit)
+	assert.Equal(t, first.Payload, atCommit.Payload)
+
+	atTime, err := g.GetBackupAt("core-01", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, latest.Commit, atTime.Commit)
+
+	_, err = g.GetBackupAt("core-01", time.Now().Add(-time.Hour))
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	_, err = g.GetBackupAtCommit("label-01", first.Commit)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	infos, err := g.List()
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	assert.Equal(t, "core-01", infos[0].Name)
+	assert.Equal(t, "Core", infos[0].DeviceType)
+	assert.Equal(t, "label-01", infos[1].Name)
+	assert.Equal(t, "Label", infos[1].DeviceType)
+}
+
+func TestDiff(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "vhs-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	g := NewGit(tempDir, "main")
+	_, err = g.SaveDeviceConfiguration(devices.NewDevice("core-01", []byte("hostname core-01\nntp server 1.1.1.1\n")))
+	require.NoError(t, err)
+	time.Sleep(time.Second) // Make sure the timestamp header differs.
+	_, err = g.SaveDeviceConfiguration(devices.NewDevice("core-01", []byte("hostname core-01\nntp server 2.2.2.2\n")))
+	require.NoError(t, err)
+
+	latest, err := g.GetLatestBackup("core-01")
+	require.NoError(t, err)
+	previous, err := g.GetPreviousBackup("core-01", latest.Commit)
+	require.NoError(t, err)
+	assert.NotEqual(t, latest.Commit, previous.Commit)
+
+	expected := "--- core-01@" + previous.Commit[:12] + "\n+++ core-01@" + latest.Commit[:12] + "\n" +
+		"@@ -1,2 +1,2 @@\n hostname core-01\n-ntp server 1.1.1.1\n+ntp server 2.2.2.2\n"
+	assert.Equal(t, expected, storage.Diff(previous, latest))
+
+	_, err = g.GetPreviousBackup("core-01", previous.Commit)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
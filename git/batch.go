@@ -0,0 +1,221 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"vhs/devices"
+	"vhs/storage"
+
+	"go.uber.org/zap"
+)
+
+// SaveDeviceConfigurations writes the configurations of several devices and commits them
+// together. The commit message lists every device in a "Device:" trailer and the collectors
+// that took their backups in "Collector:" trailers. It returns the commit holding each
+// device's configuration by device name; devices whose configuration did not change keep
+// their previous commit. When a device appears more than once the last
+// configuration wins.
+func (g *Git) SaveDeviceConfigurations(devs []devices.Device) (map[string]string, error) {
+	now := time.Now()
+	timestamp := now.Format(time.RFC3339)
+	for _, device := range devs {
+		if err := device.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	paths := make(map[string]string, len(devs))
+	saved := make(map[string]devices.Device, len(devs))
+	// companions holds the metadata and artifact files written for each device.
+	companions := make(map[string][]string)
+	var names []string
+	for _, device := range devs {
+		path := g.Layout.Path(device)
+		if err := os.MkdirAll(filepath.Join(g.RepoDir, filepath.Dir(path)), os.ModePerm); err != nil {
+			return nil, err
+		}
+		if err := g.relocate(device); err != nil {
+			return nil, err
+		}
+		if _, err := storage.WriteConfiguration(g.RepoDir, path, device.Payload, timestamp); err != nil {
+			return nil, err
+		}
+		g.paths.Set(device.Name, path)
+		written, err := storage.WriteMetadata(g.RepoDir, storage.MetadataPath(path), device.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		if written {
+			companions[device.Name] = append(companions[device.Name], storage.MetadataPath(path))
+		}
+		artifacts, err := storage.WriteArtifacts(g.RepoDir, storage.ArtifactsPath(path), device.Artifacts, timestamp)
+		if err != nil {
+			return nil, err
+		}
+		companions[device.Name] = append(companions[device.Name], artifacts...)
+		if _, ok := paths[device.Name]; !ok {
+			names = append(names, device.Name)
+		}
+		paths[device.Name] = path
+		saved[device.Name] = device
+	}
+	if len(names) == 0 {
+		return map[string]string{}, nil
+	}
+	if err := g.seen.Record(now, names...); err != nil {
+		g.log.Warn("Failed to record backups", zap.Strings("devices", names), zap.Error(err))
+	}
+
+	files := make([]string, 0, len(names))
+	for _, name := range names {
+		files = append(files, paths[name])
+		files = append(files, companions[name]...)
+	}
+	if _, err := g.runGitCommand(append([]string{"add", "--"}, files...)...); err != nil {
+		return nil, fmt.Errorf("git add failed: %w", err)
+	}
+	output, err := g.runGitCommand(append([]string{"diff", "--cached", "--name-only", "--"}, files...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list staged changes: %w", err)
+	}
+	changed := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line != "" {
+			changed[filepath.FromSlash(line)] = true
+		}
+	}
+
+	var changedNames []string
+	var changedDevs []devices.Device
+	for _, name := range names {
+		if changed[paths[name]] || anyChanged(changed, companions[name]) {
+			changed[paths[name]] = true
+			changedNames = append(changedNames, name)
+			changedDevs = append(changedDevs, saved[name])
+		}
+	}
+	head := ""
+	if len(changedNames) > 0 {
+		output, err := g.runGitCommand("commit", "-m", storage.CommitMessage(changedDevs))
+		if err != nil {
+			return nil, fmt.Errorf("git commit failed: %w, output: %s", err, output)
+		}
+		output, err = g.runGitCommand("rev-parse", "HEAD")
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve commit: %w", err)
+		}
+		head = strings.TrimSpace(string(output))
+	}
+
+	commits := make(map[string]string, len(names))
+	for _, name := range names {
+		if changed[paths[name]] {
+			commits[name] = head
+			continue
+		}
+		output, err := g.runGitCommand("log", "-1", "--format=%H", "--", paths[name])
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve commit: %w", err)
+		}
+		commits[name] = strings.TrimSpace(string(output))
+	}
+	return commits, nil
+}
+
+// anyChanged reports whether any of files is among the changed files.
+func anyChanged(changed map[string]bool, files []string) bool {
+	for _, file := range files {
+		if changed[file] {
+			return true
+		}
+	}
+	return false
+}
+
+// Batcher coalesces device saves into one commit. Saves are committed once Window has
+// passed since the first pending save or MaxDevices saves are pending, whichever is first.
+type Batcher struct {
+	git        *Git
+	window     time.Duration
+	maxDevices int
+
+	// flushMu serializes commits so batches are committed in the order they were taken.
+	flushMu sync.Mutex
+	mu      sync.Mutex
+	pending []pendingSave
+	timer   *time.Timer
+}
+
+type pendingSave struct {
+	device devices.Device
+	done   func(commit string, err error)
+}
+
+// NewBatcher creates a Batcher committing to g.
+func NewBatcher(g *Git, window time.Duration, maxDevices int) *Batcher {
+	return &Batcher{git: g, window: window, maxDevices: maxDevices}
+}
+
+// Add queues a device's configuration for the next batch. done is called with the commit
+// holding the configuration, or the error that prevented it from being committed, once the
+// batch is committed. Add itself commits the batch when it fills up.
+func (b *Batcher) Add(device devices.Device, done func(commit string, err error)) {
+	b.mu.Lock()
+	b.pending = append(b.pending, pendingSave{device: device, done: done})
+	full := len(b.pending) >= b.maxDevices
+	if !full && b.timer == nil {
+		b.timer = time.AfterFunc(b.window, b.Flush)
+	}
+	b.mu.Unlock()
+	if full {
+		b.Flush()
+	}
+}
+
+// Flush commits the pending saves right away.
+func (b *Batcher) Flush() {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
+	batch := b.pending
+	b.pending = nil
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.mu.Unlock()
+	// Invalid names would fail the whole batch, reject them on their own.
+	valid := batch[:0]
+	for _, save := range batch {
+		if err := save.device.Validate(); err != nil {
+			save.done("", err)
+			continue
+		}
+		valid = append(valid, save)
+	}
+	batch = valid
+	if len(batch) == 0 {
+		return
+	}
+
+	devs := make([]devices.Device, len(batch))
+	for i, save := range batch {
+		devs[i] = save.device
+	}
+	started := time.Now()
+	commits, err := b.git.SaveDeviceConfigurations(devs)
+	if err != nil {
+		b.git.log.Error("Failed to commit batch", zap.Int("devices", len(devs)), zap.Error(err))
+	} else {
+		b.git.log.Info("Committed batch", zap.Int("devices", len(devs)), zap.Duration("duration", time.Since(started)))
+	}
+	for _, save := range batch {
+		save.done(commits[save.device.Name], err)
+	}
+}
@@ -0,0 +1,126 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"vhs/devices"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectorTrailer(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "vhs-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	g := NewGit(tempDir, "main")
+
+	core := devices.NewDevice("core-01", []byte("hostname core-01\n"))
+	core.Metadata.Collector = "collector-a"
+	_, err = g.SaveDeviceConfiguration(core)
+	require.NoError(t, err)
+	output, err := g.runGitCommand("log", "-1", "--format=%B")
+	require.NoError(t, err)
+	assert.Equal(t, "Updated configuration for device core-01\n\nDevice: core-01\nCollector: collector-a", strings.TrimSpace(string(output)))
+
+	label := devices.NewDevice("label-01", []byte("hostname label-01\n"))
+	label.Metadata.Collector = "collector-b"
+	edge := devices.NewDevice("edge-01", []byte("hostname edge-01\n"))
+	edge.Metadata.Collector = "collector-b"
+	_, err = g.SaveDeviceConfigurations([]devices.Device{label, edge})
+	require.NoError(t, err)
+	output, err = g.runGitCommand("log", "-1", "--format=%(trailers:key=Collector,valueonly)")
+	require.NoError(t, err)
+	assert.Equal(t, "collector-b", strings.TrimSpace(string(output)))
+}
+
+func TestSaveDeviceConfigurations(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "vhs-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	g := NewGit(tempDir, "main")
+
+	commits, err := g.SaveDeviceConfigurations([]devices.Device{
+		devices.NewDevice("core-01", []byte("hostname core-01\n")),
+		devices.NewDevice("label-01", []byte("hostname label-01\n")),
+	})
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	assert.NotEmpty(t, commits["core-01"])
+	assert.Equal(t, commits["core-01"], commits["label-01"])
+
+	output, err := g.runGitCommand("log", "-1", "--format=%B")
+	require.NoError(t, err)
+	assert.Equal(t, "Updated configuration for 2 devices\n\nDevice: core-01\nDevice: label-01", strings.TrimSpace(string(output)))
+	output, err = g.runGitCommand("rev-list", "--count", "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, "1", strings.TrimSpace(string(output)))
+
+	for name, payload := range map[string]string{"core-01": "hostname core-01\n", "label-01": "hostname label-01\n"} {
+		revision, err := g.GetLatestBackup(name)
+		require.NoError(t, err)
+		assert.Equal(t, payload, string(revision.Payload))
+		assert.Equal(t, commits[name], revision.Commit)
+	}
+}
+
+func TestBatcher(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "vhs-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	g := NewGit(tempDir, "main")
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	commits := make(map[string]string)
+	add := func(b *Batcher, name string) {
+		wg.Add(1)
+		b.Add(devices.NewDevice(name, []byte("hostname "+name+"\n")), func(commit string, err error) {
+			defer wg.Done()
+			assert.NoError(t, err)
+			mu.Lock()
+			commits[name] = commit
+			mu.Unlock()
+		})
+	}
+
+	// A full batch is committed by Add without waiting for the window.
+	b := NewBatcher(&g, time.Hour, 3)
+	add(b, "core-01")
+	add(b, "core-02")
+	add(b, "core-03")
+	wg.Wait()
+	require.Len(t, commits, 3)
+	assert.NotEmpty(t, commits["core-01"])
+	assert.Equal(t, commits["core-01"], commits["core-02"])
+	assert.Equal(t, commits["core-01"], commits["core-03"])
+
+	// A partial batch is committed once the window has passed.
+	b = NewBatcher(&g, 10*time.Millisecond, 100)
+	add(b, "label-01")
+	add(b, "label-02")
+	wg.Wait()
+	assert.NotEmpty(t, commits["label-01"])
+	assert.Equal(t, commits["label-01"], commits["label-02"])
+	assert.NotEqual(t, commits["core-01"], commits["label-01"])
+
+	// An unsafe name fails on its own without holding back the rest of the batch.
+	var invalid error
+	wg.Add(1)
+	b.Add(devices.NewDevice("../core-04", []byte("hostname core-04\n")), func(commit string, err error) {
+		defer wg.Done()
+		invalid = err
+	})
+	add(b, "core-04")
+	wg.Wait()
+	assert.ErrorIs(t, invalid, devices.ErrInvalidName)
+	assert.NotEmpty(t, commits["core-04"])
+
+	output, err := g.runGitCommand("rev-list", "--count", "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, "3", strings.TrimSpace(string(output)))
+}
@@ -1,11 +1,14 @@
 package git
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
+	"vhs/devices"
 )
 
 func TestDeprecateOldFiles(t *testing.T) {
@@ -42,10 +45,13 @@ func TestDeprecateOldFiles(t *testing.T) {
 	}
 
 	// Run the deprecateOldFiles function with a max age of 24 hours
-	err = gitObj.deprecateOldFiles(tempDir, 24*time.Hour)
+	deprecated, err := gitObj.deprecateOldFiles(tempDir, 24*time.Hour)
 	if err != nil {
 		t.Fatalf("Failed to deprecate old files: %v", err)
 	}
+	if deprecated != 1 {
+		t.Errorf("Expected 1 deprecated file, got %d", deprecated)
+	}
 
 	// Check if file1 was moved to the deprecated folder
 	deprecatedFile1 := filepath.Join(tempDir, "deprecated", "file1")
@@ -63,3 +69,45 @@ func createTestFile(path string, timestamp time.Time) error {
 	content := timestamp.Format(time.RFC3339) + "\nTest content"
 	return ioutil.WriteFile(path, []byte(content), 0644)
 }
+
+func TestDeprecateWhileSaving(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "git_test_repo")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	gitObj := NewGit(tempDir, "master")
+	if err := createTestFile(filepath.Join(tempDir, "stale"), time.Now().Add(-25*time.Hour)); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := gitObj.commit("stale"); err != nil {
+		t.Fatalf("Failed to add and commit stale: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 5; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("core-%02d", i)
+			_, err := gitObj.SaveDeviceConfiguration(devices.NewDevice(name, []byte("hostname "+name+"\n")))
+			errs <- err
+		}(i)
+		go func() {
+			defer wg.Done()
+			_, err := gitObj.Deprecate(24 * time.Hour)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Concurrent save or deprecation failed: %v", err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "deprecated", "stale")); err != nil {
+		t.Errorf("stale should have been deprecated: %v", err)
+	}
+}
@@ -3,25 +3,51 @@ package git
 import (
 	"bufio"
 	"bytes"
-	"context"
 	"fmt"
 	"go.uber.org/zap"
-	"io/ioutil"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 	"vhs/devices"
+	"vhs/storage"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
+// CommandDuration observes the git commands of the git backend. It is not registered, the
+// program exposing it registers it.
+var CommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "vhs_git_command_duration_seconds",
+	Help:    "Time taken by the git commands of the git backend, by subcommand.",
+	Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+}, []string{"subcommand"})
+
 type Git struct {
 	RepoDir string
 	Branch  string
-	log     *zap.Logger
+	// Layout decides where configurations are stored in the repository; nil uses the
+	// storage.DefaultTemplate.
+	Layout *storage.Layout
+	log    *zap.Logger
+	// seen records when each device was last backed up, which deprecation goes by.
+	seen *storage.Seen
+	// paths finds the configurations in the working tree.
+	paths *storage.Index
+
+	// mu serializes changes to the working tree and index: saves, deprecation, migration
+	// and the rebase of a sync run concurrently otherwise.
+	mu sync.Mutex
 }
 
+var (
+	_ storage.Store          = (*Git)(nil)
+	_ storage.RevisionWalker = (*Git)(nil)
+)
+
 // NewGit creates a new Git object.
 func NewGit(repoDir string, branch string) Git {
 	err := os.MkdirAll(repoDir, 0755)
@@ -46,36 +72,72 @@ func NewGit(repoDir string, branch string) Git {
 		RepoDir: repoDir,
 		Branch:  branch,
 		log:     l,
+		seen:    storage.NewSeen(filepath.Join(repoDir, ".git", storage.SeenFile)),
+		paths:   storage.NewIndex(repoDir),
 	}
 }
 
-func (g *Git) SaveDeviceConfiguration(device devices.Device) error {
-	deviceDir := filepath.Join(g.RepoDir, device.GetDeviceType())
-	os.MkdirAll(deviceDir, os.ModePerm)
+// Save implements storage.Store by committing the device's configuration.
+func (g *Git) Save(device devices.Device) (string, error) {
+	return g.SaveDeviceConfiguration(device)
+}
 
-	deviceFile := filepath.Join(deviceDir, device.Name)
-	timestamp := time.Now().Format(time.RFC3339)
-	content := fmt.Sprintf("%s\n%s", timestamp, string(device.Payload))
+// SaveDeviceConfiguration writes a device's configuration and commits it. It returns the
+// commit holding the configuration, which is the previous one if nothing changed.
+func (g *Git) SaveDeviceConfiguration(device devices.Device) (string, error) {
+	if err := device.Validate(); err != nil {
+		return "", err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	path := g.Layout.Path(device)
+	deviceFile := filepath.Join(g.RepoDir, path)
+	os.MkdirAll(filepath.Dir(deviceFile), os.ModePerm)
+	if err := g.relocate(device); err != nil {
+		return "", err
+	}
 
-	if err := ioutil.WriteFile(deviceFile, []byte(content), 0644); err != nil {
-		return err
+	now := time.Now()
+	timestamp := now.Format(time.RFC3339)
+	if _, err := storage.WriteConfiguration(g.RepoDir, path, device.Payload, timestamp); err != nil {
+		return "", err
 	}
-	time.Sleep(50 * time.Millisecond) // Add sleep before git add
-	_, err := g.runGitCommand("add", deviceFile)
+	g.paths.Set(device.Name, path)
+	if err := g.seen.Record(now, device.Name); err != nil {
+		g.log.Warn("Failed to record backup", zap.String("device", device.Name), zap.Error(err))
+	}
+	files := []string{deviceFile}
+	written, err := storage.WriteMetadata(g.RepoDir, storage.MetadataPath(path), device.Metadata)
+	if err != nil {
+		return "", err
+	}
+	if written {
+		files = append(files, storage.MetadataPath(path))
+	}
+	artifacts, err := storage.WriteArtifacts(g.RepoDir, storage.ArtifactsPath(path), device.Artifacts, timestamp)
 	if err != nil {
-		return fmt.Errorf("git add failed: %w", err)
+		return "", err
 	}
-	time.Sleep(50 * time.Millisecond) // Add sleep before git commit
-	output, err := g.runGitCommand("commit", "-m", fmt.Sprintf("Updated configuration for device %s", device.Name))
+	files = append(files, artifacts...)
+	time.Sleep(50 * time.Millisecond) // Add sleep before git add
+	_, err = g.runGitCommand(append([]string{"add", "--"}, files...)...)
 	if err != nil {
+		return "", fmt.Errorf("git add failed: %w", err)
+	}
+	// Unchanged configurations are not rewritten, so there may be nothing to commit.
+	if _, err := g.runGitCommand("diff", "--cached", "--quiet"); err != nil {
+		time.Sleep(50 * time.Millisecond) // Add sleep before git commit
+		output, err := g.runGitCommand("commit", "-m", storage.CommitMessage([]devices.Device{device}))
 		// If the commit failed because there were no changes, ignore the error.
-		if bytes.Contains(output, []byte("nothing to commit, working tree clean")) {
-			return nil
+		if err != nil && !bytes.Contains(output, []byte("nothing to commit, working tree clean")) {
+			return "", fmt.Errorf("git commit failed: %w, output: %s", err, output)
 		}
-		return fmt.Errorf("git commit failed: %w, output: %s", err, output)
 	}
-	return nil
-
+	output, err := g.runGitCommand("log", "-1", "--format=%H", "--", deviceFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve commit: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
 }
 
 // commit commits changes in the Git repo.
@@ -97,12 +159,14 @@ func (g *Git) Push() error {
 }
 
 func (g *Git) Pull() error {
+	defer g.paths.Reset()
 	_, err := g.runGitCommand("pull", "origin", g.Branch)
 	return err
 }
 
 // Clone clones the remote Git repository.
 func (g *Git) Clone(repoURL string) error {
+	defer g.paths.Reset()
 	_, err := g.runGitCommand("clone", repoURL, g.RepoDir)
 	if err != nil {
 		return fmt.Errorf("git clone failed: %w", err)
@@ -118,32 +182,15 @@ func (g *Git) SetUpstreamBranch() error {
 	return nil
 }
 
-func (g *Git) StartPeriodicPush(ctx context.Context, duration time.Duration, maxAge time.Duration) {
-	ticker := time.NewTicker(duration)
-	defer ticker.Stop()
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			err := g.deprecateOldFiles(g.RepoDir, maxAge)
-			if err != nil {
-				g.log.Error("Failed to deprecate old files", zap.Error(err))
-			}
-			output, err := g.runGitCommand("push", "origin", g.Branch)
-			if err != nil {
-				g.log.Error("Failed to push changes", zap.Error(err))
-			} else {
-				if strings.Contains(string(output), "Everything up-to-date") {
-					continue
-				}
-				g.log.Info("Pushed Changes Successfully!", zap.String("output", string(output)))
-			}
-		}
-	}
+// Deprecate moves configurations older than maxAge to the deprecated folder.
+func (g *Git) Deprecate(maxAge time.Duration) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.deprecateOldFiles(g.RepoDir, maxAge)
 }
 
-func (g *Git) deprecateOldFiles(rootPath string, maxAge time.Duration) error {
+func (g *Git) deprecateOldFiles(rootPath string, maxAge time.Duration) (int, error) {
+	deprecated := 0
 	deprecatedFolderPath := filepath.Join(rootPath, "deprecated")
 	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -164,6 +211,10 @@ func (g *Git) deprecateOldFiles(rootPath string, maxAge time.Duration) error {
 			}
 			return nil
 		}
+		// Metadata and artifacts are deprecated along with their configuration.
+		if info.IsDir() && (path == filepath.Join(rootPath, storage.MetadataDir) || path == filepath.Join(rootPath, storage.ArtifactsDir)) {
+			return filepath.SkipDir
+		}
 		if !info.IsDir() {
 			g.log.Info("Scanning file", zap.String("file", path))
 			file, err := os.Open(path)
@@ -179,6 +230,15 @@ func (g *Git) deprecateOldFiles(rootPath string, maxAge time.Duration) error {
 					g.log.Warn("Failed to parse timestamp for file", zap.String("file", path))
 					return nil
 				}
+				// The header says when the configuration last changed, it may have been
+				// backed up unchanged since.
+				if rel, err := filepath.Rel(rootPath, path); err == nil {
+					if name, _, ok := g.Layout.Parse(rel); ok {
+						if last := g.seen.Last(name); last.After(timestamp) {
+							timestamp = last
+						}
+					}
+				}
 				if time.Since(timestamp) > maxAge {
 					deprecatedPath := filepath.Join(rootPath, "deprecated", strings.TrimPrefix(path, rootPath))
 					err := os.MkdirAll(filepath.Dir(deprecatedPath), os.ModePerm)
@@ -189,6 +249,7 @@ func (g *Git) deprecateOldFiles(rootPath string, maxAge time.Duration) error {
 					if err != nil {
 						return err
 					}
+					deprecated++
 					g.log.Info("Deprecated file moved", zap.String("old", path), zap.String("new", deprecatedPath))
 					_, err = g.runGitCommand("add", deprecatedPath)
 					if err != nil {
@@ -202,6 +263,15 @@ func (g *Git) deprecateOldFiles(rootPath string, maxAge time.Duration) error {
 					if err != nil {
 						g.log.Error("Failed to remove deprecated file", zap.String("file", path), zap.Error(err))
 					}
+					for _, companion := range storage.Companions(relativePath) {
+						if _, err := os.Stat(filepath.Join(rootPath, companion)); err != nil {
+							continue
+						}
+						err = g.moveFile(companion, filepath.Join(storage.DeprecatedDir, companion))
+						if err != nil {
+							g.log.Error("Failed to deprecate companion", zap.String("file", companion), zap.Error(err))
+						}
+					}
 					_, err = g.runGitCommand("commit", "-m", fmt.Sprintf("Deprecating of file  %s", path))
 					if err != nil {
 						g.log.Error("Failed to ADD for deprecated file", zap.String("file", path), zap.Error(err))
@@ -211,16 +281,66 @@ func (g *Git) deprecateOldFiles(rootPath string, maxAge time.Duration) error {
 		}
 		return nil
 	})
-	if err != nil {
+	return deprecated, err
+}
+
+// relocate moves a device's configuration to where the layout puts it when the device
+// was classified or described differently before, keeping its history together. The
+// caller holds g.mu.
+func (g *Git) relocate(device devices.Device) error {
+	path := g.Layout.Path(device)
+	old, ok := g.paths.Find(g.Layout, device.Name)
+	if !ok || old == path {
+		return nil
+	}
+	return g.move(old, path)
+}
+
+// move moves the configuration at from, along with its metadata and artifacts, to to with
+// git mv. The caller holds g.mu.
+func (g *Git) move(from string, to string) error {
+	if err := g.moveFile(from, to); err != nil {
 		return err
 	}
+	targets := storage.Companions(to)
+	for i, companion := range storage.Companions(from) {
+		if _, err := os.Stat(filepath.Join(g.RepoDir, companion)); err != nil {
+			continue
+		}
+		if err := g.moveFile(companion, targets[i]); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+func (g *Git) moveFile(from string, to string) error {
+	if err := os.MkdirAll(filepath.Join(g.RepoDir, filepath.Dir(to)), os.ModePerm); err != nil {
+		return err
+	}
+	if _, err := g.runGitCommand("mv", from, to); err != nil {
+		return fmt.Errorf("failed to move %s to %s: %w", from, to, err)
+	}
+	return nil
+}
+
+// devicePath returns the path of a device's configuration relative to the repository, or
+// of an artifact when name is a storage.ArtifactName.
+func (g *Git) devicePath(name string) string {
+	device, artifact := storage.SplitName(name)
+	path := g.paths.Locate(g.Layout, device)
+	if artifact != "" {
+		return filepath.Join(storage.ArtifactsPath(path), artifact)
+	}
+	return path
+}
+
 func (g *Git) runGitCommand(args ...string) ([]byte, error) {
 	cmd := exec.Command("git", args...)
 	cmd.Dir = g.RepoDir
+	started := time.Now()
 	output, err := cmd.CombinedOutput()
+	CommandDuration.WithLabelValues(args[0]).Observe(time.Since(started).Seconds())
 	if err != nil {
 		return output, fmt.Errorf("git command failed: %w, output: %s", err, output)
 	}
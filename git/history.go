@@ -0,0 +1,122 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"vhs/storage"
+)
+
+// History returns the commits touching a device's configuration, newest first.
+// The second return value reports whether more entries exist beyond Limit.
+func (g *Git) History(name string, opts storage.HistoryOptions) ([]storage.HistoryEntry, bool, error) {
+	if !g.hasCommits() {
+		return nil, false, nil
+	}
+	// Follow the file across moves, each record ends with the path it had in that commit.
+	args := []string{"log", "--follow", "--name-only", "--format=%x1e%H%x1f%cI%x1f%an <%ae>%x1f%s"}
+	if !opts.Since.IsZero() {
+		args = append(args, "--since="+opts.Since.Format(time.RFC3339))
+	}
+	if !opts.Until.IsZero() {
+		args = append(args, "--until="+opts.Until.Format(time.RFC3339))
+	}
+	if opts.Limit > 0 {
+		// Ask for one extra entry to learn whether another page exists. --skip cannot be
+		// used, with --follow it also counts commits not touching the file.
+		args = append(args, "-n", strconv.Itoa(opts.Skip+opts.Limit+1))
+	}
+	args = append(args, "--", g.devicePath(name))
+	output, err := g.runGitCommand(args...)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var entries []storage.HistoryEntry
+	var paths []string
+	for _, record := range strings.Split(string(output), "\x1e") {
+		lines := nonEmptyLines([]byte(record))
+		if len(lines) == 0 {
+			continue
+		}
+		fields := strings.SplitN(lines[0], "\x1f", 4)
+		if len(fields) != 4 || len(lines) < 2 {
+			return nil, false, fmt.Errorf("unexpected git log output: %q", record)
+		}
+		timestamp, err := time.Parse(time.RFC3339, fields[1])
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to parse commit time %q: %w", fields[1], err)
+		}
+		entries = append(entries, storage.HistoryEntry{
+			Commit:    fields[0],
+			Timestamp: timestamp,
+			Author:    fields[2],
+			Message:   fields[3],
+		})
+		paths = append(paths, lines[1])
+	}
+
+	if opts.Skip >= len(entries) {
+		return nil, false, nil
+	}
+	entries, paths = entries[opts.Skip:], paths[opts.Skip:]
+	more := false
+	if opts.Limit > 0 && len(entries) > opts.Limit {
+		entries = entries[:opts.Limit]
+		more = true
+	}
+	for i := range entries {
+		revision, err := g.readRevisionAt(name, entries[i].Commit, paths[i])
+		if errors.Is(err, storage.ErrNotFound) {
+			// The commit removed the file, e.g. when it was deprecated.
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		entries[i].PayloadSize = int64(len(revision.Payload))
+	}
+	return entries, more, nil
+}
+
+// WalkRevisions implements storage.RevisionWalker with every commit of the branch, so the
+// revisions of deprecated and removed devices are walked too. Files that were only moved
+// are not walked again.
+func (g *Git) WalkRevisions(fn func(revision storage.Revision) error) error {
+	if !g.hasCommits() {
+		return nil
+	}
+	output, err := g.runGitCommand("log", "-M", "--name-status", "--format=%x1e%H")
+	if err != nil {
+		return err
+	}
+	for _, record := range strings.Split(string(output), "\x1e") {
+		lines := nonEmptyLines([]byte(record))
+		if len(lines) == 0 {
+			continue
+		}
+		commit := lines[0]
+		for _, line := range lines[1:] {
+			// Lines are the status followed by the path, or the old and the new path.
+			fields := strings.Split(line, "\t")
+			status, path := fields[0], fields[len(fields)-1]
+			if strings.HasPrefix(status, "D") || status == "R100" {
+				continue
+			}
+			name, ok := g.Layout.NameAt(path)
+			if !ok {
+				continue
+			}
+			revision, err := g.readRevisionAt(name, commit, path)
+			if err != nil {
+				return err
+			}
+			if err := fn(revision); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
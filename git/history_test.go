@@ -0,0 +1,103 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+	"vhs/devices"
+	"vhs/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "vhs-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	g := NewGit(tempDir, "main")
+	entries, more, err := g.History("core-01", storage.HistoryOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	assert.False(t, more)
+
+	payloads := []string{"version 1", "version 22", "version 333"}
+	for _, payload := range payloads {
+		_, err = g.SaveDeviceConfiguration(devices.NewDevice("core-01", []byte(payload)))
+		require.NoError(t, err)
+	}
+	_, err = g.SaveDeviceConfiguration(devices.NewDevice("core-02", []byte("other device")))
+	require.NoError(t, err)
+
+	entries, more, err = g.History("core-01", storage.HistoryOptions{})
+	require.NoError(t, err)
+	assert.False(t, more)
+	require.Len(t, entries, 3)
+	for i, entry := range entries {
+		assert.Equal(t, int64(len(payloads[len(payloads)-1-i])), entry.PayloadSize)
+		assert.Equal(t, "Updated configuration for device core-01", entry.Message)
+		assert.NotEmpty(t, entry.Author)
+	}
+
+	page, more, err := g.History("core-01", storage.HistoryOptions{Limit: 2})
+	require.NoError(t, err)
+	assert.True(t, more)
+	assert.Equal(t, entries[:2], page)
+
+	page, more, err = g.History("core-01", storage.HistoryOptions{Skip: 2, Limit: 2})
+	require.NoError(t, err)
+	assert.False(t, more)
+	assert.Equal(t, entries[2:], page)
+
+	page, _, err = g.History("core-01", storage.HistoryOptions{Until: time.Now().Add(-time.Hour)})
+	require.NoError(t, err)
+	assert.Empty(t, page)
+}
+
+func TestWalkRevisions(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "vhs-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	g := NewGit(tempDir, "main")
+	for _, payload := range []string{"version 1", "version 22"} {
+		_, err = g.SaveDeviceConfiguration(devices.NewDevice("core-01", []byte(payload)))
+		require.NoError(t, err)
+	}
+	device := devices.NewDevice("edge-01", []byte("hostname edge-01"))
+	device.Artifacts = []devices.Artifact{{Name: "show_version", Payload: []byte("uptime is 2 weeks")}}
+	_, err = g.SaveDeviceConfiguration(device)
+	require.NoError(t, err)
+	// Deprecated files are walked where they were committed, not again where they moved to.
+	deprecated, err := g.Deprecate(-time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, 2, deprecated)
+	infos, err := g.List()
+	require.NoError(t, err)
+	assert.Empty(t, infos)
+
+	var walked []string
+	err = g.WalkRevisions(func(revision storage.Revision) error {
+		walked = append(walked, revision.Device+": "+string(revision.Payload))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"core-01: version 22",
+		"core-01: version 1",
+		"edge-01: hostname edge-01",
+		"edge-01/show_version: uptime is 2 weeks",
+	}, walked)
+	assert.Less(t, indexOf(walked, "core-01: version 22"), indexOf(walked, "core-01: version 1"), "newest first")
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
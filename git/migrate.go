@@ -0,0 +1,46 @@
+package git
+
+import (
+	"fmt"
+	"vhs/storage"
+
+	"go.uber.org/zap"
+)
+
+var _ storage.Migrator = (*Git)(nil)
+
+// PlanMigration returns the moves Migrate would make.
+func (g *Git) PlanMigration(from *storage.Layout) ([]storage.Move, error) {
+	infos, err := from.List(g.RepoDir)
+	if err != nil {
+		return nil, err
+	}
+	return storage.PlanMoves(g.RepoDir, from, g.Layout, infos)
+}
+
+// Migrate moves every configuration laid out by from to where g.Layout puts it, in a
+// single commit. Files are moved with git mv so that git log --follow keeps finding
+// their history.
+func (g *Git) Migrate(from *storage.Layout) ([]storage.Move, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	defer g.paths.Reset()
+	moves, err := g.PlanMigration(from)
+	if err != nil {
+		return nil, err
+	}
+	if len(moves) == 0 {
+		return nil, nil
+	}
+	for _, move := range moves {
+		if err := g.move(move.From, move.To); err != nil {
+			return nil, err
+		}
+	}
+	message := fmt.Sprintf("Moved %d configurations to layout %s", len(moves), g.Layout.Template())
+	if output, err := g.runGitCommand("commit", "-m", message); err != nil {
+		return nil, fmt.Errorf("git commit failed: %w, output: %s", err, output)
+	}
+	g.log.Info("Migrated layout", zap.Int("moves", len(moves)), zap.String("template", g.Layout.Template()))
+	return moves, nil
+}
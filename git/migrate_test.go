@@ -0,0 +1,75 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"vhs/devices"
+	"vhs/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "vhs-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	repoDir := filepath.Join(tempDir, "repo")
+	g := NewGit(repoDir, "main")
+
+	first, err := g.SaveDeviceConfiguration(devices.NewDevice("core-01", []byte("ntp server 1.1.1.1\n")))
+	require.NoError(t, err)
+	second, err := g.SaveDeviceConfiguration(devices.NewDevice("core-01", []byte("ntp server 2.2.2.2\n")))
+	require.NoError(t, err)
+	_, err = g.SaveDeviceConfiguration(devices.NewDevice("label-01", []byte("hostname label-01\n")))
+	require.NoError(t, err)
+
+	inventory := filepath.Join(tempDir, "inventory.yaml")
+	require.NoError(t, ioutil.WriteFile(inventory, []byte("core-01:\n  site: ams1\n"), 0644))
+	g.Layout, err = storage.NewLayout("{site}/{type}/{hostname}.cfg", inventory)
+	require.NoError(t, err)
+	planned, err := g.PlanMigration(nil)
+	require.NoError(t, err)
+	moves, err := g.Migrate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, planned, moves)
+	assert.Equal(t, []storage.Move{
+		{From: filepath.Join("Core", "core-01"), To: filepath.Join("ams1", "Core", "core-01.cfg")},
+		{From: filepath.Join("Label", "label-01"), To: filepath.Join("unknown", "Label", "label-01.cfg")},
+	}, moves)
+	assert.True(t, fileExists(filepath.Join(repoDir, "ams1", "Core", "core-01.cfg")))
+	assert.False(t, fileExists(filepath.Join(repoDir, "Core", "core-01")))
+	count, err := g.runGitCommand("rev-list", "--count", "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, "4\n", string(count), "the migration is a single commit")
+
+	// The history of a device reaches back past the move.
+	entries, _, err := g.History("core-01", storage.HistoryOptions{})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "Moved 2 configurations to layout {site}/{type}/{hostname}.cfg", entries[0].Message)
+	assert.Equal(t, second, entries[1].Commit)
+	assert.Equal(t, first, entries[2].Commit)
+	assert.Equal(t, int64(len("ntp server 1.1.1.1\n")), entries[2].PayloadSize)
+
+	previous, err := g.GetPreviousBackup("core-01", entries[0].Commit)
+	require.NoError(t, err)
+	assert.Equal(t, second, previous.Commit)
+	old, err := g.GetBackupAtCommit("core-01", first)
+	require.NoError(t, err)
+	assert.Equal(t, "ntp server 1.1.1.1\n", string(old.Payload))
+	diff, err := g.Diff("core-01", first, entries[0].Commit)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+ntp server 2.2.2.2\n")
+
+	infos, err := g.List()
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	assert.Equal(t, filepath.Join("ams1", "Core", "core-01.cfg"), infos[0].Path)
+
+	moves, err = g.Migrate(nil)
+	require.NoError(t, err)
+	assert.Empty(t, moves, "migrating twice does nothing")
+}
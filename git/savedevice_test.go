@@ -6,7 +6,10 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 	"vhs/devices"
+	"vhs/normalize"
+	"vhs/storage"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -44,8 +47,9 @@ func TestSaveDeviceConfiguration(t *testing.T) {
 			defer os.RemoveAll(tempDir)
 
 			g := NewGit(tempDir, "main")
-			err = g.SaveDeviceConfiguration(tc.device)
+			commit, err := g.SaveDeviceConfiguration(tc.device)
 			assert.NoError(t, err)
+			assert.NotEmpty(t, commit)
 
 			deviceFilePath := filepath.Join(tempDir, tc.device.GetDeviceType(), tc.device.Name)
 			fileExists := fileExists(deviceFilePath)
@@ -69,3 +73,167 @@ func fileExists(filename string) bool {
 	}
 	return !info.IsDir()
 }
+
+func TestSaveReclassifiedDevice(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "vhs-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	g := NewGit(tempDir, "main")
+
+	_, err = g.SaveDeviceConfiguration(devices.NewDevice("core-01", []byte("hostname core-01\n")))
+	require.NoError(t, err)
+	device := devices.NewDevice("core-01", []byte("hostname core-01\nvlan 10\n"))
+	device.Type = "Spine"
+	_, err = g.SaveDeviceConfiguration(device)
+	require.NoError(t, err)
+
+	assert.False(t, fileExists(filepath.Join(tempDir, "Core", "core-01")))
+	assert.True(t, fileExists(filepath.Join(tempDir, "Spine", "core-01")))
+	latest, err := g.GetLatestBackup("core-01")
+	require.NoError(t, err)
+	assert.Equal(t, "hostname core-01\nvlan 10\n", string(latest.Payload))
+	infos, err := g.List()
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	assert.Equal(t, "Spine", infos[0].DeviceType)
+}
+
+func TestSaveDeviceMetadata(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "vhs-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	g := NewGit(tempDir, "main")
+
+	device := devices.NewDevice("core-01", []byte("hostname core-01\n"))
+	device.Metadata = devices.Metadata{Vendor: "arista", Site: "ams1", Tags: []string{"spine"}, CollectedAt: time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)}
+	first, err := g.SaveDeviceConfiguration(device)
+	require.NoError(t, err)
+	tracked, err := g.runGitCommand("ls-files")
+	require.NoError(t, err)
+	assert.Equal(t, ".metadata/Core/core-01.yaml\nCore/core-01\n", string(tracked))
+
+	infos, err := g.List()
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	assert.Equal(t, device.Metadata, infos[0].Metadata)
+
+	// The collection time changes with every backup, it alone does not make a commit.
+	recollected := device
+	recollected.Metadata.CollectedAt = time.Date(2023, 5, 2, 12, 0, 0, 0, time.UTC)
+	second, err := g.SaveDeviceConfiguration(recollected)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	// The metadata moves with its configuration.
+	device.Type = "Spine"
+	commits, err := g.SaveDeviceConfigurations([]devices.Device{device})
+	require.NoError(t, err)
+	assert.NotEmpty(t, commits["core-01"])
+	tracked, err = g.runGitCommand("ls-files")
+	require.NoError(t, err)
+	assert.Equal(t, ".metadata/Spine/core-01.yaml\nSpine/core-01\n", string(tracked))
+
+	time.Sleep(1100 * time.Millisecond) // The timestamp header has second precision.
+	deprecated, err := g.Deprecate(time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deprecated)
+	tracked, err = g.runGitCommand("ls-files")
+	require.NoError(t, err)
+	assert.Equal(t, "deprecated/.metadata/Spine/core-01.yaml\ndeprecated/Spine/core-01\n", string(tracked))
+}
+
+func TestSaveDeviceArtifacts(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "vhs-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	g := NewGit(tempDir, "main")
+
+	device := devices.NewDevice("core-01", []byte("hostname core-01\n"))
+	device.Artifacts = []devices.Artifact{
+		{Name: "show_version", Payload: []byte("EOS 4.28\n")},
+		{Name: "show_interfaces", Payload: []byte("Ethernet1 up\n")},
+	}
+	first, err := g.SaveDeviceConfiguration(device)
+	require.NoError(t, err)
+	tracked, err := g.runGitCommand("ls-files")
+	require.NoError(t, err)
+	assert.Equal(t, ".artifacts/Core/core-01/show_interfaces\n.artifacts/Core/core-01/show_version\nCore/core-01\n", string(tracked))
+
+	// Only artifacts whose output changed get a new revision.
+	device.Artifacts[1].Payload = []byte("Ethernet1 down\n")
+	commits, err := g.SaveDeviceConfigurations([]devices.Device{device})
+	require.NoError(t, err)
+	second := commits["core-01"]
+	assert.NotEqual(t, first, second)
+	entries, _, err := g.History(storage.ArtifactName("core-01", "show_version"), storage.HistoryOptions{})
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+	diff, err := g.Diff(storage.ArtifactName("core-01", "show_interfaces"), first, second)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "-Ethernet1 up\n+Ethernet1 down\n")
+
+	infos, err := g.List()
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	assert.Equal(t, []string{"show_interfaces", "show_version"}, infos[0].Artifacts)
+
+	// The artifacts move with their configuration.
+	device.Type = "Spine"
+	_, err = g.SaveDeviceConfiguration(device)
+	require.NoError(t, err)
+	version, err := g.Get(storage.ArtifactName("core-01", "show_version"), storage.Query{Revision: first})
+	require.NoError(t, err)
+	assert.Equal(t, "EOS 4.28\n", string(version.Payload))
+
+	time.Sleep(1100 * time.Millisecond) // The timestamp header has second precision.
+	deprecated, err := g.Deprecate(time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deprecated)
+	tracked, err = g.runGitCommand("ls-files")
+	require.NoError(t, err)
+	assert.Equal(t, "deprecated/.artifacts/Spine/core-01/show_interfaces\ndeprecated/.artifacts/Spine/core-01/show_version\ndeprecated/Spine/core-01\n", string(tracked))
+}
+
+func TestSaveUnchangedConfiguration(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "vhs-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	g := NewGit(tempDir, "main")
+	normalizer, err := normalize.NewNormalizer(normalize.DefaultRules())
+	require.NoError(t, err)
+	backup := func(changed string, packets string) devices.Device {
+		device := devices.NewDevice("core-01", []byte("! Last configuration change at "+changed+"\nhostname core-01\n"))
+		device.Artifacts = []devices.Artifact{
+			{Name: "show_interfaces", Payload: []byte("Ethernet1 is up\n  " + packets + " packets input, 1024 bytes\n")},
+		}
+		return normalizer.NormalizeDevice(device)
+	}
+	commits := func() string {
+		output, err := g.runGitCommand("rev-list", "--count", "HEAD")
+		require.NoError(t, err)
+		return strings.TrimSpace(string(output))
+	}
+
+	first, err := g.SaveDeviceConfiguration(backup("10:12:01 UTC Mon May 1 2023", "100"))
+	require.NoError(t, err)
+	// The timestamp header has a resolution of a second, make sure it would differ.
+	time.Sleep(1100 * time.Millisecond)
+	second, err := g.SaveDeviceConfiguration(backup("11:40:27 UTC Mon May 1 2023", "2500"))
+	require.NoError(t, err)
+	assert.Equal(t, first, second, "a backup whose counters changed only keeps the previous commit")
+	assert.Equal(t, "1", commits())
+
+	commitsByName, err := g.SaveDeviceConfigurations([]devices.Device{backup("12:00:00 UTC Mon May 1 2023", "7000")})
+	require.NoError(t, err)
+	assert.Equal(t, first, commitsByName["core-01"])
+	assert.Equal(t, "1", commits())
+
+	// The backup is recorded although nothing was written, the device is not deprecated.
+	infos, err := g.List()
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	assert.WithinDuration(t, time.Now(), infos[0].LastBackup, time.Second)
+	deprecated, err := g.Deprecate(time.Second / 2)
+	require.NoError(t, err)
+	assert.Zero(t, deprecated)
+}
@@ -0,0 +1,89 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"vhs/storage"
+
+	"go.uber.org/zap"
+)
+
+// Sync pushes committed configurations to the remote. When the push is rejected because
+// the remote branch moved on, e.g. another VHS instance pushed to it, the local commits
+// are rebased onto the remote branch and pushed again. The rebase gives the local commits
+// new hashes, so commit hashes handed out by earlier saves may no longer exist afterwards;
+// the configuration they held is found by device name and time.
+func (g *Git) Sync() error {
+	output, err := g.runGitCommand("push", "origin", g.Branch)
+	if err != nil && isPushRejected(output) {
+		g.log.Warn("Push rejected, rebasing onto the remote branch", zap.String("branch", g.Branch))
+		if err := g.rebase(); err != nil {
+			return err
+		}
+		output, err = g.runGitCommand("push", "origin", g.Branch)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to push changes: %w", err)
+	}
+	if !strings.Contains(string(output), "Everything up-to-date") {
+		g.log.Info("Pushed Changes Successfully!", zap.String("output", string(output)))
+	}
+	return nil
+}
+
+// isPushRejected reports whether git push failed because the remote branch is not an
+// ancestor of the local one.
+func isPushRejected(output []byte) bool {
+	return strings.Contains(string(output), "[rejected]") || strings.Contains(string(output), "non-fast-forward")
+}
+
+// rebase fetches the remote branch and replays the local commits on top of it. When both
+// sides changed the same configuration the local change wins, it is the newer backup.
+func (g *Git) rebase() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	// The remote may have added or moved configurations.
+	defer g.paths.Reset()
+	if _, err := g.runGitCommand("fetch", "origin", g.Branch); err != nil {
+		return fmt.Errorf("failed to fetch changes: %w", err)
+	}
+	// During a rebase "theirs" are the local commits being replayed.
+	if _, err := g.runGitCommand("rebase", "-X", "theirs", g.remoteBranch()); err != nil {
+		if _, abortErr := g.runGitCommand("rebase", "--abort"); abortErr != nil {
+			g.log.Error("Failed to abort rebase", zap.Error(abortErr))
+		}
+		return fmt.Errorf("failed to rebase onto %s: %w", g.remoteBranch(), err)
+	}
+	return nil
+}
+
+// SyncStatus compares the branch with its remote-tracking branch, which is updated by
+// every successful Sync.
+func (g *Git) SyncStatus() (storage.SyncStatus, error) {
+	if !g.hasCommits() {
+		return storage.SyncStatus{}, nil
+	}
+	if _, err := g.runGitCommand("rev-parse", "--verify", "--quiet", g.remoteBranch()); err != nil {
+		// Nothing was pushed yet, every local commit is ahead.
+		output, err := g.runGitCommand("rev-list", "--count", "HEAD")
+		if err != nil {
+			return storage.SyncStatus{}, err
+		}
+		ahead, err := strconv.Atoi(strings.TrimSpace(string(output)))
+		return storage.SyncStatus{Ahead: ahead}, err
+	}
+	output, err := g.runGitCommand("rev-list", "--left-right", "--count", "HEAD..."+g.remoteBranch())
+	if err != nil {
+		return storage.SyncStatus{}, err
+	}
+	var status storage.SyncStatus
+	if _, err := fmt.Sscanf(string(output), "%d\t%d", &status.Ahead, &status.Behind); err != nil {
+		return storage.SyncStatus{}, fmt.Errorf("unexpected git rev-list output %q: %w", output, err)
+	}
+	return status, nil
+}
+
+func (g *Git) remoteBranch() string {
+	return "origin/" + g.Branch
+}
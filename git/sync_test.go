@@ -0,0 +1,69 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"vhs/devices"
+	"vhs/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cloneRemote clones a bare repository into a new directory of tempDir.
+func cloneRemote(t *testing.T, remote string, dir string) *Git {
+	output, err := exec.Command("git", "clone", "--quiet", remote, dir).CombinedOutput()
+	require.NoError(t, err, string(output))
+	g := NewGit(dir, "main")
+	_, err = g.runGitCommand("checkout", "-B", "main")
+	require.NoError(t, err)
+	return &g
+}
+
+func TestSyncRebasesRejectedPush(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "vhs-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	remote := filepath.Join(tempDir, "remote.git")
+	output, err := exec.Command("git", "init", "--quiet", "--bare", "--initial-branch=main", remote).CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	a := cloneRemote(t, remote, filepath.Join(tempDir, "a"))
+	_, err = a.SaveDeviceConfiguration(devices.NewDevice("core-01", []byte("from a\n")))
+	require.NoError(t, err)
+	status, err := a.SyncStatus()
+	require.NoError(t, err)
+	assert.Equal(t, storage.SyncStatus{Ahead: 1}, status)
+	require.NoError(t, a.Sync())
+
+	// A second instance pushes changes to the same device and to another one.
+	b := cloneRemote(t, remote, filepath.Join(tempDir, "b"))
+	_, err = b.SaveDeviceConfiguration(devices.NewDevice("core-01", []byte("from b\n")))
+	require.NoError(t, err)
+	_, err = b.SaveDeviceConfiguration(devices.NewDevice("label-01", []byte("from b\n")))
+	require.NoError(t, err)
+	require.NoError(t, b.Sync())
+
+	_, err = a.SaveDeviceConfiguration(devices.NewDevice("core-01", []byte("from a again\n")))
+	require.NoError(t, err)
+	require.NoError(t, a.Sync())
+
+	status, err = a.SyncStatus()
+	require.NoError(t, err)
+	assert.Equal(t, storage.SyncStatus{}, status)
+	latest, err := a.GetLatestBackup("core-01")
+	require.NoError(t, err)
+	assert.Equal(t, "from a again\n", string(latest.Payload))
+	latest, err = a.GetLatestBackup("label-01")
+	require.NoError(t, err)
+	assert.Equal(t, "from b\n", string(latest.Payload))
+
+	require.NoError(t, b.Pull())
+	latest, err = b.GetLatestBackup("core-01")
+	require.NoError(t, err)
+	assert.Equal(t, "from a again\n", string(latest.Payload))
+}
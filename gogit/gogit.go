@@ -0,0 +1,668 @@
+// Package gogit stores device configurations in a git repository using go-git, without
+// shelling out to the git binary.
+package gogit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"vhs/devices"
+	"vhs/storage"
+
+	git "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"go.uber.org/zap"
+)
+
+// Options configures a Repository.
+type Options struct {
+	// URL of the remote. Local paths and file:// URLs are handled without the git binary.
+	URL    string
+	Branch string
+	// SSHKeyFile is the private key used for ssh remotes. When empty the ssh agent is used.
+	SSHKeyFile string
+	// Author signs the commits.
+	AuthorName  string
+	AuthorEmail string
+	// Layout decides where configurations are stored in the repository; nil uses the
+	// storage.DefaultTemplate.
+	Layout *storage.Layout
+}
+
+// Repository is a storage.Store backed by a go-git working tree.
+type Repository struct {
+	dir  string
+	opts Options
+	repo *git.Repository
+	auth transport.AuthMethod
+	log  *zap.Logger
+	// seen records when each device was last backed up, which deprecation goes by.
+	seen *storage.Seen
+	// paths finds the configurations in the working tree.
+	paths *storage.Index
+
+	// mu serializes changes to the working tree and index.
+	mu sync.Mutex
+}
+
+var (
+	_ storage.Store          = (*Repository)(nil)
+	_ storage.Migrator       = (*Repository)(nil)
+	_ storage.RevisionWalker = (*Repository)(nil)
+)
+
+// Open opens the repository in dir, cloning it from opts.URL first if dir does not hold
+// one yet, and pulls the latest changes of opts.Branch.
+func Open(dir string, opts Options) (*Repository, error) {
+	l, err := zap.NewProduction()
+	if err != nil {
+		return nil, err
+	}
+	r := &Repository{
+		dir:   dir,
+		opts:  opts,
+		log:   l,
+		seen:  storage.NewSeen(filepath.Join(dir, ".git", storage.SeenFile)),
+		paths: storage.NewIndex(dir),
+	}
+	if opts.SSHKeyFile != "" {
+		r.auth, err = ssh.NewPublicKeysFromFile("git", opts.SSHKeyFile, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to load ssh key: %w", err)
+		}
+	}
+
+	r.repo, err = git.PlainOpen(dir)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		r.repo, err = r.clone()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	if err := r.Pull(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// isMissingBranch reports whether err means the remote has no commits on the branch yet.
+func isMissingBranch(err error) bool {
+	return errors.Is(err, transport.ErrEmptyRemoteRepository) || errors.Is(err, git.NoMatchingRefSpecError{})
+}
+
+// clone clones the remote, or initialises an empty repository pointing at it when the
+// remote has no commits on the branch yet.
+func (r *Repository) clone() (*git.Repository, error) {
+	repo, err := git.PlainClone(r.dir, false, &git.CloneOptions{
+		URL:           r.opts.URL,
+		Auth:          r.auth,
+		ReferenceName: r.branchRef(),
+		SingleBranch:  true,
+	})
+	if !isMissingBranch(err) {
+		return repo, err
+	}
+	// A failed clone leaves an unusable .git behind.
+	os.RemoveAll(filepath.Join(r.dir, ".git"))
+	repo, err = git.PlainInit(r.dir, false)
+	if err != nil {
+		return nil, err
+	}
+	_, err = repo.CreateRemote(&gitconfig.RemoteConfig{Name: "origin", URLs: []string{r.opts.URL}})
+	if err != nil {
+		return nil, err
+	}
+	head := plumbing.NewSymbolicReference(plumbing.HEAD, r.branchRef())
+	return repo, repo.Storer.SetReference(head)
+}
+
+// Pull fetches and merges the latest changes of the branch. An empty remote is not an error.
+func (r *Repository) Pull() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	defer r.paths.Reset()
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	err = wt.Pull(&git.PullOptions{
+		RemoteName:    "origin",
+		ReferenceName: r.branchRef(),
+		SingleBranch:  true,
+		Auth:          r.auth,
+	})
+	switch {
+	case err == nil, errors.Is(err, git.NoErrAlreadyUpToDate):
+		return nil
+	case isMissingBranch(err), errors.Is(err, plumbing.ErrReferenceNotFound) && !r.hasCommits():
+		// The remote branch does not exist yet, it will be created by the first push.
+		return nil
+	default:
+		return fmt.Errorf("failed to pull changes: %w", err)
+	}
+}
+
+// Save writes a device's configuration into the working tree and commits it.
+func (r *Repository) Save(device devices.Device) (string, error) {
+	if err := device.Validate(); err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	path := r.opts.Layout.Path(device)
+	if err := os.MkdirAll(filepath.Join(r.dir, filepath.Dir(path)), os.ModePerm); err != nil {
+		return "", err
+	}
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+	if old, ok := r.paths.Find(r.opts.Layout, device.Name); ok && old != path {
+		// The device was classified or described differently before, keep its history together.
+		if err := r.move(wt, old, path); err != nil {
+			return "", err
+		}
+	}
+	now := time.Now()
+	timestamp := now.Format(time.RFC3339)
+	if _, err := storage.WriteConfiguration(r.dir, path, device.Payload, timestamp); err != nil {
+		return "", err
+	}
+	r.paths.Set(device.Name, path)
+	if err := r.seen.Record(now, device.Name); err != nil {
+		r.log.Warn("Failed to record backup", zap.String("device", device.Name), zap.Error(err))
+	}
+	files := []string{filepath.ToSlash(path)}
+	written, err := storage.WriteMetadata(r.dir, storage.MetadataPath(path), device.Metadata)
+	if err != nil {
+		return "", err
+	}
+	if written {
+		files = append(files, filepath.ToSlash(storage.MetadataPath(path)))
+	}
+	artifacts, err := storage.WriteArtifacts(r.dir, storage.ArtifactsPath(path), device.Artifacts, timestamp)
+	if err != nil {
+		return "", err
+	}
+	for _, artifact := range artifacts {
+		files = append(files, filepath.ToSlash(artifact))
+	}
+	hashes := make(map[string]plumbing.Hash, len(files))
+	for _, file := range files {
+		hash, err := wt.Add(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to add %s: %w", file, err)
+		}
+		hashes[file] = hash
+	}
+	changed, err := r.changedSinceHead(hashes)
+	if err != nil {
+		return "", err
+	}
+	if !changed {
+		// Nothing changed, the configuration is already part of the latest commit touching it.
+		revision, err := r.latest(device.Name)
+		return revision.Commit, err
+	}
+	hash, err := wt.Commit(storage.CommitMessage([]devices.Device{device}), &git.CommitOptions{
+		Author: r.signature(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to commit %s: %w", path, err)
+	}
+	return hash.String(), nil
+}
+
+// List returns every device with a configuration in the working tree, sorted by name.
+func (r *Repository) List() ([]storage.DeviceInfo, error) {
+	infos, err := r.opts.Layout.List(r.dir)
+	if err != nil {
+		return nil, err
+	}
+	r.seen.Update(infos)
+	return infos, nil
+}
+
+// Get returns the revision of a device selected by query.
+func (r *Repository) Get(name string, query storage.Query) (storage.Revision, error) {
+	switch {
+	case query.Previous:
+		commit := query.Revision
+		if commit == "" {
+			latest, err := r.latest(name)
+			if err != nil {
+				return storage.Revision{}, err
+			}
+			commit = latest.Commit
+		}
+		hash, err := r.repo.ResolveRevision(plumbing.Revision(commit + "^"))
+		if err != nil {
+			return storage.Revision{}, fmt.Errorf("%w: no backup of %s before commit %s", storage.ErrNotFound, name, commit)
+		}
+		parent, err := r.repo.CommitObject(*hash)
+		if err != nil {
+			return storage.Revision{}, err
+		}
+		// Follow the file from where it was in the parent, it may have moved since.
+		path, err := r.pathAt(name, parent)
+		if err != nil {
+			return storage.Revision{}, err
+		}
+		return r.find(name, path, *hash, time.Time{})
+	case query.Revision != "":
+		hash, err := r.repo.ResolveRevision(plumbing.Revision(query.Revision))
+		if err != nil {
+			return storage.Revision{}, fmt.Errorf("%w: unknown commit %s", storage.ErrNotFound, query.Revision)
+		}
+		commit, err := r.repo.CommitObject(*hash)
+		if err != nil {
+			return storage.Revision{}, err
+		}
+		return r.read(name, commit)
+	case !query.At.IsZero():
+		return r.find(name, r.devicePath(name), plumbing.ZeroHash, query.At)
+	default:
+		return r.latest(name)
+	}
+}
+
+// History returns the commits touching a device's configuration, newest first.
+func (r *Repository) History(name string, opts storage.HistoryOptions) ([]storage.HistoryEntry, bool, error) {
+	if !r.hasCommits() {
+		return nil, false, nil
+	}
+	var entries []storage.HistoryEntry
+	more := false
+	skipped := 0
+	path := r.devicePath(name)
+	err := r.follow(path, plumbing.ZeroHash, func(commit *object.Commit, path string) error {
+		when := commit.Committer.When
+		if !opts.Until.IsZero() && when.After(opts.Until) {
+			return nil
+		}
+		if !opts.Since.IsZero() && when.Before(opts.Since) {
+			return nil
+		}
+		if skipped < opts.Skip {
+			skipped++
+			return nil
+		}
+		if opts.Limit > 0 && len(entries) == opts.Limit {
+			more = true
+			return storer.ErrStop
+		}
+		entry := storage.HistoryEntry{
+			Commit:    commit.Hash.String(),
+			Timestamp: commit.Committer.When,
+			Message:   strings.SplitN(commit.Message, "\n", 2)[0],
+			Author:    fmt.Sprintf("%s <%s>", commit.Author.Name, commit.Author.Email),
+		}
+		revision, err := r.readAt(name, commit, path)
+		if err == nil {
+			entry.PayloadSize = int64(len(revision.Payload))
+		} else if !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return entries, more, nil
+}
+
+// WalkRevisions implements storage.RevisionWalker with every commit of the branch, so the
+// revisions of deprecated and removed devices are walked too. Files that were only moved
+// are not walked again.
+func (r *Repository) WalkRevisions(fn func(revision storage.Revision) error) error {
+	if !r.hasCommits() {
+		return nil
+	}
+	commits, err := r.repo.Log(&git.LogOptions{})
+	if err != nil {
+		return err
+	}
+	defer commits.Close()
+	return commits.ForEach(func(commit *object.Commit) error {
+		tree, err := commit.Tree()
+		if err != nil {
+			return err
+		}
+		var parentTree *object.Tree
+		if commit.NumParents() > 0 {
+			parent, err := commit.Parent(0)
+			if err != nil {
+				return err
+			}
+			if parentTree, err = parent.Tree(); err != nil {
+				return err
+			}
+		}
+		changes, err := object.DiffTreeWithOptions(context.Background(), parentTree, tree, object.DefaultDiffTreeOptions)
+		if err != nil {
+			return err
+		}
+		for _, change := range changes {
+			if change.To.Name == "" || (change.From.Name != "" && change.From.TreeEntry.Hash == change.To.TreeEntry.Hash) {
+				// Removed or only moved.
+				continue
+			}
+			name, ok := r.opts.Layout.NameAt(change.To.Name)
+			if !ok {
+				continue
+			}
+			revision, err := r.readAt(name, commit, change.To.Name)
+			if err != nil {
+				return err
+			}
+			if err := fn(revision); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Diff returns a unified diff of a device's configuration between two commits.
+func (r *Repository) Diff(name string, from string, to string) (string, error) {
+	return storage.DiffRevisions(r, name, from, to)
+}
+
+// Deprecate moves configurations older than maxAge to the deprecated folder, one commit per file.
+func (r *Repository) Deprecate(maxAge time.Duration) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	infos, err := r.List()
+	if err != nil {
+		return 0, err
+	}
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return 0, err
+	}
+	deprecated := 0
+	for _, info := range infos {
+		if info.LastBackup.IsZero() || time.Since(info.LastBackup) <= maxAge {
+			continue
+		}
+		path := filepath.ToSlash(info.Path)
+		deprecatedPath := filepath.ToSlash(filepath.Join(storage.DeprecatedDir, path))
+		if err := r.moveFile(wt, path, deprecatedPath); err != nil {
+			return deprecated, fmt.Errorf("failed to deprecate %s: %w", path, err)
+		}
+		for _, companion := range storage.Companions(info.Path) {
+			if _, err := os.Stat(filepath.Join(r.dir, companion)); err != nil {
+				continue
+			}
+			if err := r.moveFile(wt, companion, filepath.Join(storage.DeprecatedDir, companion)); err != nil {
+				return deprecated, fmt.Errorf("failed to deprecate %s: %w", companion, err)
+			}
+		}
+		_, err := wt.Commit(fmt.Sprintf("Deprecating of file  %s", path), &git.CommitOptions{Author: r.signature()})
+		if err != nil {
+			return deprecated, fmt.Errorf("failed to commit deprecation of %s: %w", path, err)
+		}
+		deprecated++
+		r.log.Info("Deprecated file moved", zap.String("old", path), zap.String("new", deprecatedPath))
+	}
+	return deprecated, nil
+}
+
+// changedSinceHead reports whether any of the staged files, by path, holds another blob
+// than in the HEAD commit. Only the given paths are looked up; the status of the whole
+// working tree takes longer the more configurations the repository holds.
+func (r *Repository) changedSinceHead(hashes map[string]plumbing.Hash) (bool, error) {
+	if !r.hasCommits() {
+		return len(hashes) > 0, nil
+	}
+	head, err := r.repo.Head()
+	if err != nil {
+		return false, err
+	}
+	commit, err := r.repo.CommitObject(head.Hash())
+	if err != nil {
+		return false, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return false, err
+	}
+	for path, hash := range hashes {
+		entry, err := tree.FindEntry(path)
+		if errors.Is(err, object.ErrEntryNotFound) || errors.Is(err, object.ErrDirectoryNotFound) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if entry.Hash != hash {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// move moves the configuration at from, along with its metadata and artifacts, to to.
+func (r *Repository) move(wt *git.Worktree, from string, to string) error {
+	if err := r.moveFile(wt, from, to); err != nil {
+		return err
+	}
+	targets := storage.Companions(to)
+	for i, companion := range storage.Companions(from) {
+		if _, err := os.Stat(filepath.Join(r.dir, companion)); err != nil {
+			continue
+		}
+		if err := r.moveFile(wt, companion, targets[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveFile moves the file at from to to. Worktree.Move only moves files, so directories
+// are moved file by file.
+func (r *Repository) moveFile(wt *git.Worktree, from string, to string) error {
+	info, err := os.Stat(filepath.Join(r.dir, from))
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		files, err := ioutil.ReadDir(filepath.Join(r.dir, from))
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			if err := r.moveFile(wt, filepath.Join(from, file.Name()), filepath.Join(to, file.Name())); err != nil {
+				return err
+			}
+		}
+		return os.Remove(filepath.Join(r.dir, from))
+	}
+	if err := os.MkdirAll(filepath.Join(r.dir, filepath.Dir(to)), os.ModePerm); err != nil {
+		return err
+	}
+	if _, err := wt.Move(filepath.ToSlash(from), filepath.ToSlash(to)); err != nil {
+		return fmt.Errorf("failed to move %s to %s: %w", from, to, err)
+	}
+	return nil
+}
+
+// devicePath returns the path of a device's configuration in the working tree, or of an
+// artifact when name is a storage.ArtifactName.
+func (r *Repository) devicePath(name string) string {
+	device, artifact := storage.SplitName(name)
+	path := r.paths.Locate(r.opts.Layout, device)
+	if artifact != "" {
+		return filepath.Join(storage.ArtifactsPath(path), artifact)
+	}
+	return path
+}
+
+func (r *Repository) latest(name string) (storage.Revision, error) {
+	return r.find(name, r.devicePath(name), plumbing.ZeroHash, time.Time{})
+}
+
+// find returns the configuration in the newest commit touching a device's file, stored at
+// path, starting from the commit from (HEAD when zero) and skipping commits made after
+// until unless it is zero.
+func (r *Repository) find(name string, path string, from plumbing.Hash, until time.Time) (storage.Revision, error) {
+	if !r.hasCommits() {
+		return storage.Revision{}, storage.ErrNotFound
+	}
+	revision, err := storage.Revision{}, error(storage.ErrNotFound)
+	followErr := r.follow(path, from, func(commit *object.Commit, path string) error {
+		if !until.IsZero() && commit.Committer.When.After(until) {
+			return nil
+		}
+		revision, err = r.readAt(name, commit, path)
+		return errStopFollow
+	})
+	if followErr != nil && !errors.Is(followErr, errStopFollow) {
+		return storage.Revision{}, followErr
+	}
+	return revision, err
+}
+
+// errStopFollow ends follow early.
+var errStopFollow = errors.New("stop following")
+
+// follow calls fn with every commit touching the file at path, newest first, starting from
+// the commit from (HEAD when zero), along with the path the file had in that commit. Like
+// git log --follow, it carries on with the old path when it reaches the commit that moved
+// the file there. An error returned by fn ends the walk and is returned.
+func (r *Repository) follow(path string, from plumbing.Hash, fn func(commit *object.Commit, path string) error) error {
+	path = filepath.ToSlash(path)
+	for {
+		commits, err := r.repo.Log(&git.LogOptions{From: from, FileName: &path})
+		if err != nil {
+			return err
+		}
+		var oldest *object.Commit
+		err = commits.ForEach(func(commit *object.Commit) error {
+			oldest = commit
+			return fn(commit, path)
+		})
+		commits.Close()
+		if err != nil || oldest == nil {
+			return err
+		}
+		previous, ok, err := r.renamedFrom(oldest, path)
+		if err != nil || !ok {
+			return err
+		}
+		from, path = oldest.ParentHashes[0], previous
+	}
+}
+
+// renamedFrom returns the path a commit moved the file at path from, if it did.
+func (r *Repository) renamedFrom(commit *object.Commit, path string) (string, bool, error) {
+	if commit.NumParents() == 0 {
+		return "", false, nil
+	}
+	parent, err := commit.Parent(0)
+	if err != nil {
+		return "", false, err
+	}
+	if _, err := parent.File(path); err == nil {
+		return "", false, nil
+	}
+	parentTree, err := parent.Tree()
+	if err != nil {
+		return "", false, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return "", false, err
+	}
+	changes, err := object.DiffTreeWithOptions(context.Background(), parentTree, tree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return "", false, err
+	}
+	for _, change := range changes {
+		if change.To.Name == path && change.From.Name != "" && change.From.Name != path {
+			return change.From.Name, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// pathAt returns where a device's file is stored in a commit. Files that were moved, e.g.
+// by a layout migration, are found at their old path in older commits.
+func (r *Repository) pathAt(name string, commit *object.Commit) (string, error) {
+	current := filepath.ToSlash(r.devicePath(name))
+	if _, err := commit.File(current); err == nil || !r.hasCommits() {
+		return current, nil
+	}
+	found := ""
+	err := r.follow(current, plumbing.ZeroHash, func(_ *object.Commit, path string) error {
+		if _, err := commit.File(path); err == nil {
+			found = path
+			return errStopFollow
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopFollow) {
+		return "", err
+	}
+	if found == "" {
+		return "", fmt.Errorf("%w: device %s not present in commit %s", storage.ErrNotFound, name, commit.Hash)
+	}
+	return found, nil
+}
+
+// read reads a device's file from a commit.
+func (r *Repository) read(name string, commit *object.Commit) (storage.Revision, error) {
+	path, err := r.pathAt(name, commit)
+	if err != nil {
+		return storage.Revision{}, err
+	}
+	return r.readAt(name, commit, path)
+}
+
+// readAt reads a device's file from a commit, where it is stored at path.
+func (r *Repository) readAt(name string, commit *object.Commit, path string) (storage.Revision, error) {
+	file, err := commit.File(filepath.ToSlash(path))
+	if err != nil {
+		return storage.Revision{}, fmt.Errorf("%w: device %s not present in commit %s", storage.ErrNotFound, name, commit.Hash)
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return storage.Revision{}, err
+	}
+	timestamp, payload := storage.SplitHeader([]byte(content))
+	return storage.Revision{
+		Device:    name,
+		Commit:    commit.Hash.String(),
+		Timestamp: timestamp,
+		Payload:   payload,
+	}, nil
+}
+
+func (r *Repository) hasCommits() bool {
+	_, err := r.repo.Head()
+	return err == nil
+}
+
+func (r *Repository) branchRef() plumbing.ReferenceName {
+	return plumbing.NewBranchReferenceName(r.opts.Branch)
+}
+
+func (r *Repository) signature() *object.Signature {
+	return &object.Signature{
+		Name:  r.opts.AuthorName,
+		Email: r.opts.AuthorEmail,
+		When:  time.Now(),
+	}
+}
@@ -0,0 +1,172 @@
+package gogit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"vhs/devices"
+	"vhs/storage"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository(t *testing.T) {
+	// None of this may depend on the git binary.
+	t.Setenv("PATH", "")
+
+	tempDir, err := ioutil.TempDir("", "vhs-gogit")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	remote := filepath.Join(tempDir, "remote.git")
+	_, err = git.PlainInit(remote, true)
+	require.NoError(t, err)
+	opts := Options{URL: remote, Branch: "main", AuthorName: "VHS", AuthorEmail: "vhs@example.com"}
+
+	r, err := Open(filepath.Join(tempDir, "clone"), opts)
+	require.NoError(t, err)
+	_, err = r.Get("core-01", storage.Query{})
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	first, err := r.Save(devices.NewDevice("core-01", []byte("ntp server 1.1.1.1\n")))
+	require.NoError(t, err)
+	second, err := r.Save(devices.NewDevice("core-01", []byte("ntp server 2.2.2.2\n")))
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+	_, err = r.Save(devices.NewDevice("label-01", []byte("hostname label-01\n")))
+	require.NoError(t, err)
+	unchanged, err := r.Save(devices.NewDevice("core-01", []byte("ntp server 2.2.2.2\n")))
+	require.NoError(t, err)
+	assert.Equal(t, second, unchanged, "saving the same configuration does not create a commit")
+
+	latest, err := r.Get("core-01", storage.Query{})
+	require.NoError(t, err)
+	assert.Equal(t, second, latest.Commit)
+	assert.Equal(t, "ntp server 2.2.2.2\n", string(latest.Payload))
+
+	previous, err := r.Get("core-01", storage.Query{Previous: true})
+	require.NoError(t, err)
+	assert.Equal(t, first, previous.Commit)
+
+	byCommit, err := r.Get("core-01", storage.Query{Revision: first[:10]})
+	require.NoError(t, err)
+	assert.Equal(t, "ntp server 1.1.1.1\n", string(byCommit.Payload))
+
+	_, err = r.Get("core-01", storage.Query{At: time.Now().Add(-time.Hour)})
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	entries, more, err := r.History("core-01", storage.HistoryOptions{Limit: 1})
+	require.NoError(t, err)
+	assert.True(t, more)
+	require.Len(t, entries, 1)
+	assert.Equal(t, second, entries[0].Commit)
+	assert.Equal(t, "VHS <vhs@example.com>", entries[0].Author)
+	assert.Equal(t, "Updated configuration for device core-01", entries[0].Message)
+	assert.Equal(t, int64(len("ntp server 2.2.2.2\n")), entries[0].PayloadSize)
+
+	diff, err := r.Diff("core-01", first, second)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "-ntp server 1.1.1.1\n+ntp server 2.2.2.2\n")
+
+	infos, err := r.List()
+	require.NoError(t, err)
+	assert.Len(t, infos, 2)
+
+	// A device classified differently keeps its history under the new type.
+	reclassified := devices.NewDevice("label-01", []byte("hostname label-01\nvlan 10\n"))
+	reclassified.Type = "Access"
+	reclassified.Artifacts = []devices.Artifact{{Name: "show_version", Payload: []byte("JunOS 22.4\n")}}
+	_, err = r.Save(reclassified)
+	require.NoError(t, err)
+	infos, err = r.List()
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	assert.Equal(t, "Access", infos[1].DeviceType)
+	moved, err := r.Get("label-01", storage.Query{})
+	require.NoError(t, err)
+	assert.Equal(t, "hostname label-01\nvlan 10\n", string(moved.Payload))
+	version, err := r.Get(storage.ArtifactName("label-01", "show_version"), storage.Query{})
+	require.NoError(t, err)
+	assert.Equal(t, "JunOS 22.4\n", string(version.Payload))
+	_, err = r.Save(reclassified)
+	require.NoError(t, err)
+	entries, _, err = r.History(storage.ArtifactName("label-01", "show_version"), storage.HistoryOptions{})
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "unchanged artifacts are not rewritten")
+
+	require.NoError(t, r.Sync())
+
+	// A second clone of the remote sees everything that was pushed.
+	other, err := Open(filepath.Join(tempDir, "other"), opts)
+	require.NoError(t, err)
+	pushed, err := other.Get("core-01", storage.Query{})
+	require.NoError(t, err)
+	assert.Equal(t, second, pushed.Commit)
+
+	time.Sleep(1100 * time.Millisecond) // The timestamp header has second precision.
+	deprecated, err := r.Deprecate(time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 2, deprecated)
+	infos, err = r.List()
+	require.NoError(t, err)
+	assert.Empty(t, infos)
+	assert.FileExists(t, filepath.Join(tempDir, "clone", "deprecated", "Core", "core-01"))
+	assert.FileExists(t, filepath.Join(tempDir, "clone", "deprecated", "Access", "label-01"))
+	assert.FileExists(t, filepath.Join(tempDir, "clone", "deprecated", storage.ArtifactsDir, "Access", "label-01", "show_version"))
+	require.NoError(t, r.Sync())
+}
+
+func TestMigrate(t *testing.T) {
+	t.Setenv("PATH", "")
+
+	tempDir, err := ioutil.TempDir("", "vhs-gogit")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	remote := filepath.Join(tempDir, "remote.git")
+	_, err = git.PlainInit(remote, true)
+	require.NoError(t, err)
+	opts := Options{URL: remote, Branch: "main", AuthorName: "VHS", AuthorEmail: "vhs@example.com"}
+	r, err := Open(filepath.Join(tempDir, "clone"), opts)
+	require.NoError(t, err)
+	first, err := r.Save(devices.NewDevice("core-01", []byte("ntp server 1.1.1.1\n")))
+	require.NoError(t, err)
+	device := devices.NewDevice("core-01", []byte("ntp server 2.2.2.2\n"))
+	device.Metadata = devices.Metadata{Vendor: "arista", Tags: []string{"spine"}}
+	second, err := r.Save(device)
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(tempDir, "clone", ".metadata", "Core", "core-01.yaml"))
+
+	r.opts.Layout, err = storage.NewLayout("{site}/{hostname}.cfg", "")
+	require.NoError(t, err)
+	moves, err := r.Migrate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []storage.Move{{From: filepath.Join("Core", "core-01"), To: filepath.Join("unknown", "core-01.cfg")}}, moves)
+	assert.FileExists(t, filepath.Join(tempDir, "clone", "unknown", "core-01.cfg"))
+	infos, err := r.List()
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	assert.Equal(t, device.Metadata, infos[0].Metadata, "the metadata moves with the configuration")
+
+	// The history of a device reaches back past the move.
+	entries, _, err := r.History("core-01", storage.HistoryOptions{})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "Moved 1 configurations to layout {site}/{hostname}.cfg", entries[0].Message)
+	assert.Equal(t, second, entries[1].Commit)
+	assert.Equal(t, first, entries[2].Commit)
+
+	previous, err := r.Get("core-01", storage.Query{Revision: entries[0].Commit, Previous: true})
+	require.NoError(t, err)
+	assert.Equal(t, second, previous.Commit)
+	old, err := r.Get("core-01", storage.Query{Revision: first})
+	require.NoError(t, err)
+	assert.Equal(t, "ntp server 1.1.1.1\n", string(old.Payload))
+	before, err := r.Get("core-01", storage.Query{At: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, "ntp server 2.2.2.2\n", string(before.Payload))
+}
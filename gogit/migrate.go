@@ -0,0 +1,48 @@
+package gogit
+
+import (
+	"fmt"
+	"vhs/storage"
+
+	git "github.com/go-git/go-git/v5"
+	"go.uber.org/zap"
+)
+
+// PlanMigration returns the moves Migrate would make.
+func (r *Repository) PlanMigration(from *storage.Layout) ([]storage.Move, error) {
+	infos, err := from.List(r.dir)
+	if err != nil {
+		return nil, err
+	}
+	return storage.PlanMoves(r.dir, from, r.opts.Layout, infos)
+}
+
+// Migrate moves every configuration laid out by from to where opts.Layout puts it, in a
+// single commit.
+func (r *Repository) Migrate(from *storage.Layout) ([]storage.Move, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	defer r.paths.Reset()
+	moves, err := r.PlanMigration(from)
+	if err != nil {
+		return nil, err
+	}
+	if len(moves) == 0 {
+		return nil, nil
+	}
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	for _, move := range moves {
+		if err := r.move(wt, move.From, move.To); err != nil {
+			return nil, err
+		}
+	}
+	message := fmt.Sprintf("Moved %d configurations to layout %s", len(moves), r.opts.Layout.Template())
+	if _, err := wt.Commit(message, &git.CommitOptions{Author: r.signature()}); err != nil {
+		return nil, fmt.Errorf("failed to commit migration: %w", err)
+	}
+	r.log.Info("Migrated layout", zap.Int("moves", len(moves)), zap.String("template", r.opts.Layout.Template()))
+	return moves, nil
+}
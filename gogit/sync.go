@@ -0,0 +1,256 @@
+package gogit
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"vhs/storage"
+
+	git "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"go.uber.org/zap"
+)
+
+// Sync pushes committed configurations to the remote. When the push is rejected because
+// the remote branch moved on, e.g. another VHS instance pushed to it, the local commits
+// are rebased onto the remote branch and pushed again. The rebase gives the local commits
+// new hashes, so commit hashes handed out by earlier saves may no longer exist afterwards;
+// the configuration they held is found by device name and time.
+func (r *Repository) Sync() error {
+	// go-git is not safe for concurrent use, saves must not touch the repository while it
+	// is pushed or rebased.
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.hasCommits() {
+		return nil
+	}
+	err := r.push()
+	if isPushRejected(err) {
+		r.log.Warn("Push rejected, rebasing onto the remote branch", zap.String("branch", r.opts.Branch))
+		if err := r.rebase(); err != nil {
+			return err
+		}
+		err = r.push()
+	}
+	if errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to push changes: %w", err)
+	}
+	r.log.Info("Pushed Changes Successfully!")
+	return nil
+}
+
+// isPushRejected reports whether a push failed because the remote branch is not an
+// ancestor of the local one. go-git does not wrap a sentinel error for every case.
+func isPushRejected(err error) bool {
+	return err != nil && (errors.Is(err, git.ErrForceNeeded) || strings.Contains(err.Error(), "non-fast-forward update"))
+}
+
+func (r *Repository) push() error {
+	return r.repo.Push(&git.PushOptions{
+		RemoteName: "origin",
+		RefSpecs:   []gitconfig.RefSpec{gitconfig.RefSpec(r.branchRef() + ":" + r.branchRef())},
+		Auth:       r.auth,
+	})
+}
+
+// rebase fetches the remote branch and replays the local commits on top of it. go-git
+// cannot rebase, so every local commit is re-created from the files it changed. When both
+// sides changed the same configuration the local change wins, it is the newer backup.
+// The caller holds r.mu.
+func (r *Repository) rebase() error {
+	// The remote may have added or moved configurations.
+	defer r.paths.Reset()
+	err := r.repo.Fetch(&git.FetchOptions{
+		RemoteName: "origin",
+		RefSpecs:   []gitconfig.RefSpec{gitconfig.RefSpec("+" + r.branchRef() + ":" + r.remoteRef())},
+		Auth:       r.auth,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("failed to fetch changes: %w", err)
+	}
+	local, remote, err := r.heads()
+	if err != nil {
+		return err
+	}
+	bases, err := local.MergeBase(remote)
+	if err != nil {
+		return err
+	}
+	var base plumbing.Hash
+	if len(bases) > 0 {
+		base = bases[0].Hash
+	}
+	var replay []*object.Commit
+	err = r.walk(local, base, func(commit *object.Commit) {
+		replay = append([]*object.Commit{commit}, replay...)
+	})
+	if err != nil {
+		return err
+	}
+
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	if err := wt.Reset(&git.ResetOptions{Commit: remote.Hash, Mode: git.HardReset}); err != nil {
+		return fmt.Errorf("failed to reset to %s: %w", remote.Hash, err)
+	}
+	for _, commit := range replay {
+		if err := r.apply(wt, commit); err != nil {
+			return fmt.Errorf("failed to replay commit %s: %w", commit.Hash, err)
+		}
+		_, err := wt.Commit(commit.Message, &git.CommitOptions{Author: &commit.Author, Committer: r.signature()})
+		if errors.Is(err, git.ErrEmptyCommit) {
+			// The remote already has the same content.
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to replay commit %s: %w", commit.Hash, err)
+		}
+	}
+	return nil
+}
+
+// apply writes the files a commit changed relative to its parent into the working tree
+// and stages them.
+func (r *Repository) apply(wt *git.Worktree, commit *object.Commit) error {
+	tree, err := commit.Tree()
+	if err != nil {
+		return err
+	}
+	parentTree := &object.Tree{}
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return err
+		}
+	}
+	changes, err := parentTree.Diff(tree)
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		if change.To.Name == "" {
+			if _, err := os.Stat(filepath.Join(r.dir, change.From.Name)); err == nil {
+				if _, err := wt.Remove(change.From.Name); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if change.From.Name != "" && change.From.Name != change.To.Name {
+			if _, err := os.Stat(filepath.Join(r.dir, change.From.Name)); err == nil {
+				if _, err := wt.Remove(change.From.Name); err != nil {
+					return err
+				}
+			}
+		}
+		file, err := tree.File(change.To.Name)
+		if err != nil {
+			return err
+		}
+		content, err := file.Contents()
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(r.dir, filepath.FromSlash(change.To.Name))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			return err
+		}
+		if _, err := wt.Add(change.To.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SyncStatus compares the branch with its remote-tracking branch, which is updated by
+// every successful Sync.
+func (r *Repository) SyncStatus() (storage.SyncStatus, error) {
+	if !r.hasCommits() {
+		return storage.SyncStatus{}, nil
+	}
+	local, remote, err := r.heads()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		// Nothing was pushed yet, every local commit is ahead.
+		head, err := r.repo.Head()
+		if err != nil {
+			return storage.SyncStatus{}, err
+		}
+		if local, err = r.repo.CommitObject(head.Hash()); err != nil {
+			return storage.SyncStatus{}, err
+		}
+		var status storage.SyncStatus
+		err = r.walk(local, plumbing.ZeroHash, func(*object.Commit) { status.Ahead++ })
+		return status, err
+	}
+	if err != nil {
+		return storage.SyncStatus{}, err
+	}
+	bases, err := local.MergeBase(remote)
+	if err != nil {
+		return storage.SyncStatus{}, err
+	}
+	var base plumbing.Hash
+	if len(bases) > 0 {
+		base = bases[0].Hash
+	}
+	var status storage.SyncStatus
+	if err := r.walk(local, base, func(*object.Commit) { status.Ahead++ }); err != nil {
+		return storage.SyncStatus{}, err
+	}
+	if err := r.walk(remote, base, func(*object.Commit) { status.Behind++ }); err != nil {
+		return storage.SyncStatus{}, err
+	}
+	return status, nil
+}
+
+// heads returns the commits the local branch and its remote-tracking branch point at.
+func (r *Repository) heads() (*object.Commit, *object.Commit, error) {
+	var commits [2]*object.Commit
+	for i, name := range []plumbing.ReferenceName{r.branchRef(), r.remoteRef()} {
+		ref, err := r.repo.Reference(name, true)
+		if err != nil {
+			return nil, nil, err
+		}
+		if commits[i], err = r.repo.CommitObject(ref.Hash()); err != nil {
+			return nil, nil, err
+		}
+	}
+	return commits[0], commits[1], nil
+}
+
+// walk calls fn for from and its ancestors, newest first, stopping at until.
+func (r *Repository) walk(from *object.Commit, until plumbing.Hash, fn func(*object.Commit)) error {
+	commits, err := r.repo.Log(&git.LogOptions{From: from.Hash})
+	if err != nil {
+		return err
+	}
+	defer commits.Close()
+	return commits.ForEach(func(commit *object.Commit) error {
+		if commit.Hash == until {
+			return storer.ErrStop
+		}
+		fn(commit)
+		return nil
+	})
+}
+
+func (r *Repository) remoteRef() plumbing.ReferenceName {
+	return plumbing.NewRemoteReferenceName("origin", r.opts.Branch)
+}
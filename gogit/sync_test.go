@@ -0,0 +1,114 @@
+package gogit
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"vhs/devices"
+	"vhs/storage"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncRebasesRejectedPush(t *testing.T) {
+	t.Setenv("PATH", "")
+
+	tempDir, err := ioutil.TempDir("", "vhs-gogit")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	remote := filepath.Join(tempDir, "remote.git")
+	_, err = git.PlainInit(remote, true)
+	require.NoError(t, err)
+	opts := Options{URL: remote, Branch: "main", AuthorName: "VHS", AuthorEmail: "vhs@example.com"}
+
+	a, err := Open(filepath.Join(tempDir, "a"), opts)
+	require.NoError(t, err)
+	_, err = a.Save(devices.NewDevice("core-01", []byte("from a\n")))
+	require.NoError(t, err)
+	status, err := a.SyncStatus()
+	require.NoError(t, err)
+	assert.Equal(t, storage.SyncStatus{Ahead: 1}, status)
+	require.NoError(t, a.Sync())
+
+	// A second instance pushes changes to the same device and to another one.
+	b, err := Open(filepath.Join(tempDir, "b"), opts)
+	require.NoError(t, err)
+	_, err = b.Save(devices.NewDevice("core-01", []byte("from b\n")))
+	require.NoError(t, err)
+	_, err = b.Save(devices.NewDevice("label-01", []byte("from b\n")))
+	require.NoError(t, err)
+	require.NoError(t, b.Sync())
+
+	_, err = a.Save(devices.NewDevice("core-01", []byte("from a again\n")))
+	require.NoError(t, err)
+	require.NoError(t, a.Sync())
+
+	status, err = a.SyncStatus()
+	require.NoError(t, err)
+	assert.Equal(t, storage.SyncStatus{}, status)
+	latest, err := a.Get("core-01", storage.Query{})
+	require.NoError(t, err)
+	assert.Equal(t, "from a again\n", string(latest.Payload))
+	latest, err = a.Get("label-01", storage.Query{})
+	require.NoError(t, err)
+	assert.Equal(t, "from b\n", string(latest.Payload))
+	history, _, err := a.History("core-01", storage.HistoryOptions{})
+	require.NoError(t, err)
+	assert.Len(t, history, 3)
+
+	require.NoError(t, b.Pull())
+	latest, err = b.Get("core-01", storage.Query{})
+	require.NoError(t, err)
+	assert.Equal(t, "from a again\n", string(latest.Payload))
+}
+
+func TestSyncWhileSaving(t *testing.T) {
+	t.Setenv("PATH", "")
+
+	tempDir, err := ioutil.TempDir("", "vhs-gogit")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	remote := filepath.Join(tempDir, "remote.git")
+	_, err = git.PlainInit(remote, true)
+	require.NoError(t, err)
+	opts := Options{URL: remote, Branch: "main", AuthorName: "VHS", AuthorEmail: "vhs@example.com"}
+	r, err := Open(filepath.Join(tempDir, "clone"), opts)
+	require.NoError(t, err)
+	_, err = r.Save(devices.NewDevice("core-00", []byte("hostname core-00\n")))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 1; i <= 5; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("core-%02d", i)
+			_, err := r.Save(devices.NewDevice(name, []byte("hostname "+name+"\n")))
+			errs <- err
+		}(i)
+		go func() {
+			defer wg.Done()
+			errs <- r.Sync()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err, "concurrent save or sync failed")
+	}
+	require.NoError(t, r.Sync())
+	status, err := r.SyncStatus()
+	require.NoError(t, err)
+	assert.Equal(t, storage.SyncStatus{}, status)
+	infos, err := r.List()
+	require.NoError(t, err)
+	assert.Len(t, infos, 6)
+}
@@ -0,0 +1,53 @@
+package gogit
+
+import (
+	"context"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+)
+
+func init() {
+	// Serve file:// remotes in process instead of running git-upload-pack and git-receive-pack.
+	client.InstallProtocol("file", fileTransport{server.DefaultServer})
+}
+
+// fileTransport is server.DefaultServer ignoring commits a fetching client has but the
+// remote does not, like git-upload-pack does. go-git fails the whole fetch on them, which
+// breaks fetching into a clone with unpushed commits.
+type fileTransport struct {
+	transport.Transport
+}
+
+func (t fileTransport) NewUploadPackSession(ep *transport.Endpoint, auth transport.AuthMethod) (transport.UploadPackSession, error) {
+	session, err := t.Transport.NewUploadPackSession(ep, auth)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := server.DefaultLoader.Load(ep)
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	return &uploadPackSession{UploadPackSession: session, objects: objects}, nil
+}
+
+type uploadPackSession struct {
+	transport.UploadPackSession
+	objects storer.EncodedObjectStorer
+}
+
+func (s *uploadPackSession) UploadPack(ctx context.Context, req *packp.UploadPackRequest) (*packp.UploadPackResponse, error) {
+	var haves []plumbing.Hash
+	for _, hash := range req.Haves {
+		if s.objects.HasEncodedObject(hash) == nil {
+			haves = append(haves, hash)
+		}
+	}
+	req.Haves = haves
+	return s.UploadPackSession.UploadPack(ctx, req)
+}
@@ -0,0 +1,182 @@
+// Package jobs tracks the progress of accepted backups from the spool to the remote repository.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrUnknownJob is returned when waiting for a job the Tracker does not know about.
+var ErrUnknownJob = errors.New("unknown job")
+
+// State is the stage a backup job has reached.
+type State string
+
+const (
+	Queued    State = "queued"
+	Committed State = "committed"
+	Pushed    State = "pushed"
+	Failed    State = "failed"
+)
+
+// Job is a snapshot of a backup's progress. Error holds the latest failure, which for a
+// queued job means the last commit attempt failed and will be retried. Commit is not
+// updated when a rebase before the push gives the commit a new hash.
+type Job struct {
+	ID       string
+	Device   string
+	State    State
+	Commit   string
+	Error    string
+	Attempts int
+	Updated  time.Time
+}
+
+type job struct {
+	Job
+	// settled is closed once the job is committed or failed for good.
+	settled chan struct{}
+}
+
+// Tracker keeps the state of recent jobs in memory. Once more than limit jobs are tracked
+// the oldest finished ones are forgotten.
+type Tracker struct {
+	limit int
+
+	mu    sync.Mutex
+	jobs  map[string]*job
+	order []string
+}
+
+// NewTracker creates a Tracker remembering up to limit jobs.
+func NewTracker(limit int) *Tracker {
+	return &Tracker{
+		limit: limit,
+		jobs:  make(map[string]*job),
+	}
+}
+
+// Queued records a job that has been accepted but not committed yet.
+func (t *Tracker) Queued(id string, device string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.get(id, device)
+}
+
+// Committed records that a job's configuration is part of commit.
+func (t *Tracker) Committed(id string, device string, commit string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	j := t.get(id, device)
+	j.State = Committed
+	j.Commit = commit
+	j.Error = ""
+	j.Attempts++
+	j.Updated = time.Now()
+	j.settle()
+}
+
+// AttemptFailed records a failed commit attempt. When final is set the job will not be
+// retried and is marked as failed.
+func (t *Tracker) AttemptFailed(id string, device string, err error, final bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	j := t.get(id, device)
+	j.Error = err.Error()
+	j.Attempts++
+	j.Updated = time.Now()
+	if final {
+		j.State = Failed
+		j.settle()
+	}
+}
+
+// Pushed marks every job committed before the push started as pushed.
+func (t *Tracker) Pushed(started time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, j := range t.jobs {
+		if j.State == Committed && !j.Updated.After(started) {
+			j.State = Pushed
+			j.Updated = time.Now()
+		}
+	}
+}
+
+// Get returns the current state of a job.
+func (t *Tracker) Get(id string) (Job, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	j, ok := t.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return j.Job, true
+}
+
+// Wait blocks until a job has been committed or failed for good, or ctx is done. Failed
+// attempts that will be retried do not end the wait.
+func (t *Tracker) Wait(ctx context.Context, id string) (Job, error) {
+	t.mu.Lock()
+	j, ok := t.jobs[id]
+	t.mu.Unlock()
+	if !ok {
+		return Job{}, ErrUnknownJob
+	}
+	select {
+	case <-ctx.Done():
+		return Job{}, ctx.Err()
+	case <-j.settled:
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return j.Job, nil
+}
+
+// get returns the job with the given id, creating it as queued if it is unknown.
+func (t *Tracker) get(id string, device string) *job {
+	if j, ok := t.jobs[id]; ok {
+		return j
+	}
+	j := &job{
+		Job: Job{
+			ID:      id,
+			Device:  device,
+			State:   Queued,
+			Updated: time.Now(),
+		},
+		settled: make(chan struct{}),
+	}
+	t.jobs[id] = j
+	t.order = append(t.order, id)
+	t.evict()
+	return j
+}
+
+// evict forgets the oldest jobs that are no longer queued while over the limit.
+func (t *Tracker) evict() {
+	if len(t.order) <= t.limit {
+		return
+	}
+	kept := t.order[:0]
+	excess := len(t.order) - t.limit
+	for _, id := range t.order {
+		if excess > 0 && t.jobs[id].State != Queued {
+			delete(t.jobs, id)
+			excess--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	t.order = kept
+}
+
+func (j *job) settle() {
+	select {
+	case <-j.settled:
+	default:
+		close(j.settled)
+	}
+}
@@ -0,0 +1,77 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackerLifecycle(t *testing.T) {
+	tracker := NewTracker(10)
+	tracker.Queued("1", "core-01")
+	job, ok := tracker.Get("1")
+	require.True(t, ok)
+	assert.Equal(t, Queued, job.State)
+
+	tracker.AttemptFailed("1", "core-01", errors.New("index.lock exists"), false)
+	job, _ = tracker.Get("1")
+	assert.Equal(t, Queued, job.State)
+	assert.Equal(t, "index.lock exists", job.Error)
+	assert.Equal(t, 1, job.Attempts)
+	short, cancelShort := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelShort()
+	_, err := tracker.Wait(short, "1")
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "a failed attempt that will be retried does not end the wait")
+
+	go tracker.Committed("1", "core-01", "abc123")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	job, err = tracker.Wait(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, Committed, job.State)
+	assert.Equal(t, 2, job.Attempts)
+	assert.Equal(t, "abc123", job.Commit)
+	assert.Empty(t, job.Error)
+
+	tracker.Pushed(job.Updated.Add(-time.Second))
+	job, _ = tracker.Get("1")
+	assert.Equal(t, Committed, job.State, "committed after the push started")
+	tracker.Pushed(time.Now())
+	job, _ = tracker.Get("1")
+	assert.Equal(t, Pushed, job.State)
+
+	tracker.AttemptFailed("2", "core-02", errors.New("disk full"), true)
+	job, err = tracker.Wait(ctx, "2")
+	require.NoError(t, err)
+	assert.Equal(t, Failed, job.State)
+
+	_, err = tracker.Wait(ctx, "3")
+	assert.ErrorIs(t, err, ErrUnknownJob)
+}
+
+func TestTrackerWaitTimeout(t *testing.T) {
+	tracker := NewTracker(10)
+	tracker.Queued("1", "core-01")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := tracker.Wait(ctx, "1")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestTrackerEviction(t *testing.T) {
+	tracker := NewTracker(2)
+	tracker.Queued("1", "core-01")
+	tracker.Committed("2", "core-02", "abc")
+	tracker.Committed("3", "core-03", "def")
+
+	_, ok := tracker.Get("1")
+	assert.True(t, ok, "queued jobs are kept")
+	_, ok = tracker.Get("2")
+	assert.False(t, ok)
+	_, ok = tracker.Get("3")
+	assert.True(t, ok)
+}
@@ -0,0 +1,257 @@
+// Package normalize strips volatile content, such as counters, uptimes and timestamps, from
+// device output before it is stored, so that only real changes produce new revisions.
+package normalize
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"vhs/devices"
+)
+
+// Configuration is the command the configuration payload of a backup is normalized as.
+// Artifacts are normalized as the command they are named after.
+const Configuration = "configuration"
+
+// Mask replaces every number on a line matched by a MaskNumbers pattern. Digits that are
+// part of a word, such as the 01 in core-01, are not numbers.
+const Mask = "#"
+
+// Rule normalizes the output of the commands it matches. Every rule matching a device and
+// command is applied, in order.
+type Rule struct {
+	// Vendor limits the rule to devices whose metadata names this vendor, compared
+	// case-insensitively. Empty matches every device.
+	Vendor string `yaml:"vendor,omitempty"`
+	// Command is a regular expression matched against the artifact name, e.g.
+	// "^show_interfaces", or Configuration for the configuration payload. Empty matches
+	// every command.
+	Command string `yaml:"command,omitempty"`
+	// DropLines are regular expressions; lines matching any of them are removed.
+	DropLines []string `yaml:"drop_lines,omitempty"`
+	// MaskNumbers are regular expressions; every number on lines matching any of them is
+	// replaced by Mask.
+	MaskNumbers []string `yaml:"mask_numbers,omitempty"`
+	// Replace rewrites every match of a pattern on a line.
+	Replace []Replacement `yaml:"replace,omitempty"`
+}
+
+// Replacement replaces the matches of Pattern by With, which may refer to capture groups
+// like "$1" or "${name}".
+type Replacement struct {
+	Pattern string `yaml:"pattern"`
+	With    string `yaml:"with"`
+}
+
+// DefaultRules strip the volatile headers IOS, NX-OS and Junos put in front of the
+// configuration, and mask the counters and uptimes of show interfaces and show version.
+func DefaultRules() []Rule {
+	return []Rule{
+		{
+			Command: "^" + Configuration + "$",
+			DropLines: []string{
+				`^! Last configuration change at `,
+				`^! NVRAM config last updated at `,
+				`^! No configuration change since last restart`,
+				`^!Time: `,
+				`^## Last commit: `,
+			},
+		},
+		{
+			Command: "^show_interfaces",
+			MaskNumbers: []string{
+				`(?i)packets (input|output)`,
+				`(?i)(input|output) rate`,
+				`(?i)(input|output) errors`,
+				`(?i)(broadcasts|multicast|runts|giants|throttles|collisions|interface resets|underruns|output drops)`,
+				`(?i)^\s*last (input|output|clearing|link flapped)`,
+			},
+		},
+		{
+			Command: "^show_version",
+			MaskNumbers: []string{
+				`(?i)uptime is`,
+				`(?i)up time`,
+			},
+		},
+	}
+}
+
+// Normalizer applies rules to the output of devices.
+type Normalizer struct {
+	rules []rule
+}
+
+type rule struct {
+	vendor      string
+	command     *regexp.Regexp
+	dropLines   []*regexp.Regexp
+	maskNumbers []*regexp.Regexp
+	replace     []replacement
+}
+
+type replacement struct {
+	pattern *regexp.Regexp
+	with    string
+}
+
+// NewNormalizer compiles rules into a Normalizer.
+func NewNormalizer(rules []Rule) (*Normalizer, error) {
+	n := &Normalizer{}
+	var errs []error
+	for i, r := range rules {
+		compiled, err := compileRule(r)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rule %d: %w", i+1, err))
+			continue
+		}
+		n.rules = append(n.rules, compiled)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func compileRule(r Rule) (rule, error) {
+	if len(r.DropLines) == 0 && len(r.MaskNumbers) == 0 && len(r.Replace) == 0 {
+		return rule{}, errors.New("at least one of drop_lines, mask_numbers and replace must be set")
+	}
+	compiled := rule{vendor: r.Vendor}
+	var err error
+	if r.Command != "" {
+		if compiled.command, err = regexp.Compile(r.Command); err != nil {
+			return rule{}, fmt.Errorf("invalid command pattern: %w", err)
+		}
+	}
+	if compiled.dropLines, err = compileAll(r.DropLines); err != nil {
+		return rule{}, fmt.Errorf("invalid drop_lines pattern: %w", err)
+	}
+	if compiled.maskNumbers, err = compileAll(r.MaskNumbers); err != nil {
+		return rule{}, fmt.Errorf("invalid mask_numbers pattern: %w", err)
+	}
+	for _, replace := range r.Replace {
+		pattern, err := regexp.Compile(replace.Pattern)
+		if err != nil {
+			return rule{}, fmt.Errorf("invalid replace pattern: %w", err)
+		}
+		compiled.replace = append(compiled.replace, replacement{pattern: pattern, with: replace.With})
+	}
+	return compiled, nil
+}
+
+func compileAll(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// NormalizeDevice normalizes a device's configuration and artifacts by the rules matching
+// its vendor. The device passed in is not modified.
+func (n *Normalizer) NormalizeDevice(device devices.Device) devices.Device {
+	vendor := device.Metadata.Vendor
+	device.Payload = n.Normalize(vendor, Configuration, device.Payload)
+	if device.Artifacts != nil {
+		artifacts := make([]devices.Artifact, len(device.Artifacts))
+		for i, artifact := range device.Artifacts {
+			artifacts[i] = devices.Artifact{Name: artifact.Name, Payload: n.Normalize(vendor, artifact.Name, artifact.Payload)}
+		}
+		device.Artifacts = artifacts
+	}
+	return device
+}
+
+// Normalize applies the rules matching vendor and command to output, line by line.
+// Output no rule matches is returned unchanged.
+func (n *Normalizer) Normalize(vendor string, command string, output []byte) []byte {
+	var rules []rule
+	for _, r := range n.rules {
+		if r.matches(vendor, command) {
+			rules = append(rules, r)
+		}
+	}
+	if len(rules) == 0 {
+		return output
+	}
+	lines := bytes.SplitAfter(output, []byte("\n"))
+	normalized := make([]byte, 0, len(output))
+	for _, line := range lines {
+		if line, ok := normalizeLine(rules, line); ok {
+			normalized = append(normalized, line...)
+		}
+	}
+	return normalized
+}
+
+func (r rule) matches(vendor string, command string) bool {
+	if r.vendor != "" && !strings.EqualFold(r.vendor, vendor) {
+		return false
+	}
+	return r.command == nil || r.command.MatchString(command)
+}
+
+// normalizeLine applies rules to a line, keeping its line ending. It returns false when
+// the line is dropped.
+func normalizeLine(rules []rule, line []byte) ([]byte, bool) {
+	text := bytes.TrimRight(line, "\r\n")
+	ending := line[len(text):]
+	for _, r := range rules {
+		if matchesAny(r.dropLines, text) {
+			return nil, false
+		}
+		if matchesAny(r.maskNumbers, text) {
+			text = maskNumbers(text)
+		}
+		for _, replace := range r.replace {
+			text = replace.pattern.ReplaceAll(text, []byte(replace.with))
+		}
+	}
+	return append(append([]byte(nil), text...), ending...), true
+}
+
+// maskNumbers replaces every number in text by Mask.
+func maskNumbers(text []byte) []byte {
+	var masked []byte
+	for i := 0; i < len(text); i++ {
+		if !isDigit(text[i]) || (i > 0 && isWordByte(text[i-1])) {
+			masked = append(masked, text[i])
+			continue
+		}
+		end := i
+		for end < len(text) && isDigit(text[end]) {
+			end++
+		}
+		if end < len(text) && isWordByte(text[end]) && !isDigit(text[end]) {
+			masked = append(masked, text[i:end]...)
+		} else {
+			masked = append(masked, Mask...)
+		}
+		i = end - 1
+	}
+	return masked
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+func isWordByte(b byte) bool {
+	return isDigit(b) || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b == '-' || b == '_'
+}
+
+func matchesAny(patterns []*regexp.Regexp, line []byte) bool {
+	for _, pattern := range patterns {
+		if pattern.Match(line) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package normalize
+
+import (
+	"testing"
+	"vhs/devices"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultRules(t *testing.T) {
+	n, err := NewNormalizer(DefaultRules())
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		command  string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Configuration change header",
+			command:  Configuration,
+			input:    "!\r\n! Last configuration change at 10:12:01 UTC Mon May 1 2023 by admin\r\n! NVRAM config last updated at 10:12:05 UTC Mon May 1 2023\r\nhostname core-01\r\n",
+			expected: "!\r\nhostname core-01\r\n",
+		},
+		{
+			name:     "Junos commit header",
+			command:  Configuration,
+			input:    "## Last commit: 2023-05-01 10:12:01 UTC by admin\nversion 22.4R1;\n",
+			expected: "version 22.4R1;\n",
+		},
+		{
+			name:     "Interface counters",
+			command:  "show_interfaces",
+			input:    "GigabitEthernet0/1 is up, line protocol is up\n  MTU 1500 bytes, BW 1000000 Kbit/sec\n  5 minute input rate 2000 bits/sec, 3 packets/sec\n     1234 packets input, 567890 bytes, 0 no buffer\n  Last input 00:00:01, output never, output hang never",
+			expected: "GigabitEthernet0/1 is up, line protocol is up\n  MTU 1500 bytes, BW 1000000 Kbit/sec\n  # minute input rate # bits/sec, # packets/sec\n     # packets input, # bytes, # no buffer\n  Last input #:#:#, output never, output hang never",
+		},
+		{
+			name:     "Uptime",
+			command:  "show_version",
+			input:    "Cisco IOS Software, Version 15.2(4)M3\ncore-01 uptime is 1 week, 2 days, 3 hours\n",
+			expected: "Cisco IOS Software, Version 15.2(4)M3\ncore-01 uptime is # week, # days, # hours\n",
+		},
+		{
+			name:     "Commands without rules",
+			command:  "show_lldp_neighbors",
+			input:    "Last input 00:00:01\n",
+			expected: "Last input 00:00:01\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, string(n.Normalize("cisco", tc.command, []byte(tc.input))))
+		})
+	}
+}
+
+func TestNormalizeDevice(t *testing.T) {
+	n, err := NewNormalizer([]Rule{
+		{Vendor: "Arista", Command: "^show_clock$", DropLines: []string{"."}},
+		{Command: "^show_ntp", Replace: []Replacement{{Pattern: `offset (-?[0-9.]+)`, With: "offset <offset>"}}},
+	})
+	require.NoError(t, err)
+
+	device := devices.NewDevice("core-01", []byte("hostname core-01\n"))
+	device.Metadata.Vendor = "arista"
+	device.Artifacts = []devices.Artifact{
+		{Name: "show_clock", Payload: []byte("10:12:01.123 UTC Mon May 1 2023\n")},
+		{Name: "show_ntp_status", Payload: []byte("synchronised, offset -0.25 ms\n")},
+	}
+	normalized := n.NormalizeDevice(device)
+	assert.Equal(t, "hostname core-01\n", string(normalized.Payload))
+	assert.Empty(t, normalized.Artifacts[0].Payload)
+	assert.Equal(t, "synchronised, offset <offset> ms\n", string(normalized.Artifacts[1].Payload))
+	assert.Equal(t, "synchronised, offset -0.25 ms\n", string(device.Artifacts[1].Payload), "the device passed in is not modified")
+
+	device.Metadata.Vendor = "juniper"
+	assert.NotEmpty(t, n.NormalizeDevice(device).Artifacts[0].Payload, "vendor rules only apply to that vendor")
+}
+
+func TestNewNormalizerErrors(t *testing.T) {
+	_, err := NewNormalizer([]Rule{
+		{Command: "show"},
+		{Command: "(", DropLines: []string{"x"}},
+		{MaskNumbers: []string{"["}},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rule 1: at least one of drop_lines, mask_numbers and replace must be set")
+	assert.Contains(t, err.Error(), "rule 2: invalid command pattern")
+	assert.Contains(t, err.Error(), "rule 3: invalid mask_numbers pattern")
+}
@@ -33,6 +33,20 @@ const _ = twirp.TwirpPackageMinVersion_8_1_0
 
 type VhsService interface {
 	Backup(context.Context, *BackupRequest) (*BackupResponse, error)
+
+	ListDevices(context.Context, *ListDevicesRequest) (*ListDevicesResponse, error)
+
+	GetLatestBackup(context.Context, *GetLatestBackupRequest) (*GetBackupResponse, error)
+
+	GetBackupAt(context.Context, *GetBackupAtRequest) (*GetBackupResponse, error)
+
+	GetDeviceHistory(context.Context, *GetDeviceHistoryRequest) (*GetDeviceHistoryResponse, error)
+
+	DiffBackup(context.Context, *DiffBackupRequest) (*DiffBackupResponse, error)
+
+	GetBackupStatus(context.Context, *GetBackupStatusRequest) (*GetBackupStatusResponse, error)
+
+	GetSyncStatus(context.Context, *GetSyncStatusRequest) (*GetSyncStatusResponse, error)
 }
 
 // ==========================
@@ -41,7 +55,7 @@ type VhsService interface {
 
 type vhsServiceProtobufClient struct {
 	client      HTTPClient
-	urls        [1]string
+	urls        [8]string
 	interceptor twirp.Interceptor
 	opts        twirp.ClientOptions
 }
@@ -69,8 +83,15 @@ func NewVhsServiceProtobufClient(baseURL string, client HTTPClient, opts ...twir
 	// Build method URLs: <baseURL>[<prefix>]/<package>.<Service>/<Method>
 	serviceURL := sanitizeBaseURL(baseURL)
 	serviceURL += baseServicePath(pathPrefix, "pkg.cache.server", "VhsService")
-	urls := [1]string{
+	urls := [8]string{
 		serviceURL + "Backup",
+		serviceURL + "ListDevices",
+		serviceURL + "GetLatestBackup",
+		serviceURL + "GetBackupAt",
+		serviceURL + "GetDeviceHistory",
+		serviceURL + "DiffBackup",
+		serviceURL + "GetBackupStatus",
+		serviceURL + "GetSyncStatus",
 	}
 
 	return &vhsServiceProtobufClient{
@@ -127,13 +148,335 @@ func (c *vhsServiceProtobufClient) callBackup(ctx context.Context, in *BackupReq
 	return out, nil
 }
 
+func (c *vhsServiceProtobufClient) ListDevices(ctx context.Context, in *ListDevicesRequest) (*ListDevicesResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "pkg.cache.server")
+	ctx = ctxsetters.WithServiceName(ctx, "VhsService")
+	ctx = ctxsetters.WithMethodName(ctx, "ListDevices")
+	caller := c.callListDevices
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *ListDevicesRequest) (*ListDevicesResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ListDevicesRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ListDevicesRequest) when calling interceptor")
+					}
+					return c.callListDevices(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ListDevicesResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ListDevicesResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *vhsServiceProtobufClient) callListDevices(ctx context.Context, in *ListDevicesRequest) (*ListDevicesResponse, error) {
+	out := new(ListDevicesResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[1], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *vhsServiceProtobufClient) GetLatestBackup(ctx context.Context, in *GetLatestBackupRequest) (*GetBackupResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "pkg.cache.server")
+	ctx = ctxsetters.WithServiceName(ctx, "VhsService")
+	ctx = ctxsetters.WithMethodName(ctx, "GetLatestBackup")
+	caller := c.callGetLatestBackup
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *GetLatestBackupRequest) (*GetBackupResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetLatestBackupRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetLatestBackupRequest) when calling interceptor")
+					}
+					return c.callGetLatestBackup(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetBackupResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetBackupResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *vhsServiceProtobufClient) callGetLatestBackup(ctx context.Context, in *GetLatestBackupRequest) (*GetBackupResponse, error) {
+	out := new(GetBackupResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[2], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *vhsServiceProtobufClient) GetBackupAt(ctx context.Context, in *GetBackupAtRequest) (*GetBackupResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "pkg.cache.server")
+	ctx = ctxsetters.WithServiceName(ctx, "VhsService")
+	ctx = ctxsetters.WithMethodName(ctx, "GetBackupAt")
+	caller := c.callGetBackupAt
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *GetBackupAtRequest) (*GetBackupResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetBackupAtRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetBackupAtRequest) when calling interceptor")
+					}
+					return c.callGetBackupAt(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetBackupResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetBackupResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *vhsServiceProtobufClient) callGetBackupAt(ctx context.Context, in *GetBackupAtRequest) (*GetBackupResponse, error) {
+	out := new(GetBackupResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[3], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *vhsServiceProtobufClient) GetDeviceHistory(ctx context.Context, in *GetDeviceHistoryRequest) (*GetDeviceHistoryResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "pkg.cache.server")
+	ctx = ctxsetters.WithServiceName(ctx, "VhsService")
+	ctx = ctxsetters.WithMethodName(ctx, "GetDeviceHistory")
+	caller := c.callGetDeviceHistory
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *GetDeviceHistoryRequest) (*GetDeviceHistoryResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetDeviceHistoryRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetDeviceHistoryRequest) when calling interceptor")
+					}
+					return c.callGetDeviceHistory(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetDeviceHistoryResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetDeviceHistoryResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *vhsServiceProtobufClient) callGetDeviceHistory(ctx context.Context, in *GetDeviceHistoryRequest) (*GetDeviceHistoryResponse, error) {
+	out := new(GetDeviceHistoryResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[4], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *vhsServiceProtobufClient) DiffBackup(ctx context.Context, in *DiffBackupRequest) (*DiffBackupResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "pkg.cache.server")
+	ctx = ctxsetters.WithServiceName(ctx, "VhsService")
+	ctx = ctxsetters.WithMethodName(ctx, "DiffBackup")
+	caller := c.callDiffBackup
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *DiffBackupRequest) (*DiffBackupResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*DiffBackupRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*DiffBackupRequest) when calling interceptor")
+					}
+					return c.callDiffBackup(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*DiffBackupResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*DiffBackupResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *vhsServiceProtobufClient) callDiffBackup(ctx context.Context, in *DiffBackupRequest) (*DiffBackupResponse, error) {
+	out := new(DiffBackupResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[5], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *vhsServiceProtobufClient) GetBackupStatus(ctx context.Context, in *GetBackupStatusRequest) (*GetBackupStatusResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "pkg.cache.server")
+	ctx = ctxsetters.WithServiceName(ctx, "VhsService")
+	ctx = ctxsetters.WithMethodName(ctx, "GetBackupStatus")
+	caller := c.callGetBackupStatus
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *GetBackupStatusRequest) (*GetBackupStatusResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetBackupStatusRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetBackupStatusRequest) when calling interceptor")
+					}
+					return c.callGetBackupStatus(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetBackupStatusResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetBackupStatusResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *vhsServiceProtobufClient) callGetBackupStatus(ctx context.Context, in *GetBackupStatusRequest) (*GetBackupStatusResponse, error) {
+	out := new(GetBackupStatusResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[6], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *vhsServiceProtobufClient) GetSyncStatus(ctx context.Context, in *GetSyncStatusRequest) (*GetSyncStatusResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "pkg.cache.server")
+	ctx = ctxsetters.WithServiceName(ctx, "VhsService")
+	ctx = ctxsetters.WithMethodName(ctx, "GetSyncStatus")
+	caller := c.callGetSyncStatus
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *GetSyncStatusRequest) (*GetSyncStatusResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetSyncStatusRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetSyncStatusRequest) when calling interceptor")
+					}
+					return c.callGetSyncStatus(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetSyncStatusResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetSyncStatusResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *vhsServiceProtobufClient) callGetSyncStatus(ctx context.Context, in *GetSyncStatusRequest) (*GetSyncStatusResponse, error) {
+	out := new(GetSyncStatusResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[7], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
 // ======================
 // VhsService JSON Client
 // ======================
 
 type vhsServiceJSONClient struct {
 	client      HTTPClient
-	urls        [1]string
+	urls        [8]string
 	interceptor twirp.Interceptor
 	opts        twirp.ClientOptions
 }
@@ -144,189 +487,1799 @@ func NewVhsServiceJSONClient(baseURL string, client HTTPClient, opts ...twirp.Cl
 	if c, ok := client.(*http.Client); ok {
 		client = withoutRedirects(c)
 	}
-
-	clientOpts := twirp.ClientOptions{}
-	for _, o := range opts {
-		o(&clientOpts)
+
+	clientOpts := twirp.ClientOptions{}
+	for _, o := range opts {
+		o(&clientOpts)
+	}
+
+	// Using ReadOpt allows backwards and forwards compatibility with new options in the future
+	literalURLs := false
+	_ = clientOpts.ReadOpt("literalURLs", &literalURLs)
+	var pathPrefix string
+	if ok := clientOpts.ReadOpt("pathPrefix", &pathPrefix); !ok {
+		pathPrefix = "/twirp" // default prefix
+	}
+
+	// Build method URLs: <baseURL>[<prefix>]/<package>.<Service>/<Method>
+	serviceURL := sanitizeBaseURL(baseURL)
+	serviceURL += baseServicePath(pathPrefix, "pkg.cache.server", "VhsService")
+	urls := [8]string{
+		serviceURL + "Backup",
+		serviceURL + "ListDevices",
+		serviceURL + "GetLatestBackup",
+		serviceURL + "GetBackupAt",
+		serviceURL + "GetDeviceHistory",
+		serviceURL + "DiffBackup",
+		serviceURL + "GetBackupStatus",
+		serviceURL + "GetSyncStatus",
+	}
+
+	return &vhsServiceJSONClient{
+		client:      client,
+		urls:        urls,
+		interceptor: twirp.ChainInterceptors(clientOpts.Interceptors...),
+		opts:        clientOpts,
+	}
+}
+
+func (c *vhsServiceJSONClient) Backup(ctx context.Context, in *BackupRequest) (*BackupResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "pkg.cache.server")
+	ctx = ctxsetters.WithServiceName(ctx, "VhsService")
+	ctx = ctxsetters.WithMethodName(ctx, "Backup")
+	caller := c.callBackup
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *BackupRequest) (*BackupResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*BackupRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*BackupRequest) when calling interceptor")
+					}
+					return c.callBackup(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*BackupResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*BackupResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *vhsServiceJSONClient) callBackup(ctx context.Context, in *BackupRequest) (*BackupResponse, error) {
+	out := new(BackupResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[0], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *vhsServiceJSONClient) ListDevices(ctx context.Context, in *ListDevicesRequest) (*ListDevicesResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "pkg.cache.server")
+	ctx = ctxsetters.WithServiceName(ctx, "VhsService")
+	ctx = ctxsetters.WithMethodName(ctx, "ListDevices")
+	caller := c.callListDevices
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *ListDevicesRequest) (*ListDevicesResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ListDevicesRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ListDevicesRequest) when calling interceptor")
+					}
+					return c.callListDevices(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ListDevicesResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ListDevicesResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *vhsServiceJSONClient) callListDevices(ctx context.Context, in *ListDevicesRequest) (*ListDevicesResponse, error) {
+	out := new(ListDevicesResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[1], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *vhsServiceJSONClient) GetLatestBackup(ctx context.Context, in *GetLatestBackupRequest) (*GetBackupResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "pkg.cache.server")
+	ctx = ctxsetters.WithServiceName(ctx, "VhsService")
+	ctx = ctxsetters.WithMethodName(ctx, "GetLatestBackup")
+	caller := c.callGetLatestBackup
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *GetLatestBackupRequest) (*GetBackupResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetLatestBackupRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetLatestBackupRequest) when calling interceptor")
+					}
+					return c.callGetLatestBackup(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetBackupResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetBackupResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *vhsServiceJSONClient) callGetLatestBackup(ctx context.Context, in *GetLatestBackupRequest) (*GetBackupResponse, error) {
+	out := new(GetBackupResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[2], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *vhsServiceJSONClient) GetBackupAt(ctx context.Context, in *GetBackupAtRequest) (*GetBackupResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "pkg.cache.server")
+	ctx = ctxsetters.WithServiceName(ctx, "VhsService")
+	ctx = ctxsetters.WithMethodName(ctx, "GetBackupAt")
+	caller := c.callGetBackupAt
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *GetBackupAtRequest) (*GetBackupResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetBackupAtRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetBackupAtRequest) when calling interceptor")
+					}
+					return c.callGetBackupAt(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetBackupResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetBackupResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *vhsServiceJSONClient) callGetBackupAt(ctx context.Context, in *GetBackupAtRequest) (*GetBackupResponse, error) {
+	out := new(GetBackupResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[3], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *vhsServiceJSONClient) GetDeviceHistory(ctx context.Context, in *GetDeviceHistoryRequest) (*GetDeviceHistoryResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "pkg.cache.server")
+	ctx = ctxsetters.WithServiceName(ctx, "VhsService")
+	ctx = ctxsetters.WithMethodName(ctx, "GetDeviceHistory")
+	caller := c.callGetDeviceHistory
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *GetDeviceHistoryRequest) (*GetDeviceHistoryResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetDeviceHistoryRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetDeviceHistoryRequest) when calling interceptor")
+					}
+					return c.callGetDeviceHistory(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetDeviceHistoryResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetDeviceHistoryResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *vhsServiceJSONClient) callGetDeviceHistory(ctx context.Context, in *GetDeviceHistoryRequest) (*GetDeviceHistoryResponse, error) {
+	out := new(GetDeviceHistoryResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[4], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *vhsServiceJSONClient) DiffBackup(ctx context.Context, in *DiffBackupRequest) (*DiffBackupResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "pkg.cache.server")
+	ctx = ctxsetters.WithServiceName(ctx, "VhsService")
+	ctx = ctxsetters.WithMethodName(ctx, "DiffBackup")
+	caller := c.callDiffBackup
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *DiffBackupRequest) (*DiffBackupResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*DiffBackupRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*DiffBackupRequest) when calling interceptor")
+					}
+					return c.callDiffBackup(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*DiffBackupResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*DiffBackupResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *vhsServiceJSONClient) callDiffBackup(ctx context.Context, in *DiffBackupRequest) (*DiffBackupResponse, error) {
+	out := new(DiffBackupResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[5], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *vhsServiceJSONClient) GetBackupStatus(ctx context.Context, in *GetBackupStatusRequest) (*GetBackupStatusResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "pkg.cache.server")
+	ctx = ctxsetters.WithServiceName(ctx, "VhsService")
+	ctx = ctxsetters.WithMethodName(ctx, "GetBackupStatus")
+	caller := c.callGetBackupStatus
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *GetBackupStatusRequest) (*GetBackupStatusResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetBackupStatusRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetBackupStatusRequest) when calling interceptor")
+					}
+					return c.callGetBackupStatus(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetBackupStatusResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetBackupStatusResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *vhsServiceJSONClient) callGetBackupStatus(ctx context.Context, in *GetBackupStatusRequest) (*GetBackupStatusResponse, error) {
+	out := new(GetBackupStatusResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[6], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *vhsServiceJSONClient) GetSyncStatus(ctx context.Context, in *GetSyncStatusRequest) (*GetSyncStatusResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "pkg.cache.server")
+	ctx = ctxsetters.WithServiceName(ctx, "VhsService")
+	ctx = ctxsetters.WithMethodName(ctx, "GetSyncStatus")
+	caller := c.callGetSyncStatus
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *GetSyncStatusRequest) (*GetSyncStatusResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetSyncStatusRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetSyncStatusRequest) when calling interceptor")
+					}
+					return c.callGetSyncStatus(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetSyncStatusResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetSyncStatusResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *vhsServiceJSONClient) callGetSyncStatus(ctx context.Context, in *GetSyncStatusRequest) (*GetSyncStatusResponse, error) {
+	out := new(GetSyncStatusResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[7], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+// =========================
+// VhsService Server Handler
+// =========================
+
+type vhsServiceServer struct {
+	VhsService
+	interceptor      twirp.Interceptor
+	hooks            *twirp.ServerHooks
+	pathPrefix       string // prefix for routing
+	jsonSkipDefaults bool   // do not include unpopulated fields (default values) in the response
+	jsonCamelCase    bool   // JSON fields are serialized as lowerCamelCase rather than keeping the original proto names
+}
+
+// NewVhsServiceServer builds a TwirpServer that can be used as an http.Handler to handle
+// HTTP requests that are routed to the right method in the provided svc implementation.
+// The opts are twirp.ServerOption modifiers, for example twirp.WithServerHooks(hooks).
+func NewVhsServiceServer(svc VhsService, opts ...interface{}) TwirpServer {
+	serverOpts := newServerOpts(opts)
+
+	// Using ReadOpt allows backwards and forwards compatibility with new options in the future
+	jsonSkipDefaults := false
+	_ = serverOpts.ReadOpt("jsonSkipDefaults", &jsonSkipDefaults)
+	jsonCamelCase := false
+	_ = serverOpts.ReadOpt("jsonCamelCase", &jsonCamelCase)
+	var pathPrefix string
+	if ok := serverOpts.ReadOpt("pathPrefix", &pathPrefix); !ok {
+		pathPrefix = "/twirp" // default prefix
+	}
+
+	return &vhsServiceServer{
+		VhsService:       svc,
+		hooks:            serverOpts.Hooks,
+		interceptor:      twirp.ChainInterceptors(serverOpts.Interceptors...),
+		pathPrefix:       pathPrefix,
+		jsonSkipDefaults: jsonSkipDefaults,
+		jsonCamelCase:    jsonCamelCase,
+	}
+}
+
+// writeError writes an HTTP response with a valid Twirp error format, and triggers hooks.
+// If err is not a twirp.Error, it will get wrapped with twirp.InternalErrorWith(err)
+func (s *vhsServiceServer) writeError(ctx context.Context, resp http.ResponseWriter, err error) {
+	writeError(ctx, resp, err, s.hooks)
+}
+
+// handleRequestBodyError is used to handle error when the twirp server cannot read request
+func (s *vhsServiceServer) handleRequestBodyError(ctx context.Context, resp http.ResponseWriter, msg string, err error) {
+	if context.Canceled == ctx.Err() {
+		s.writeError(ctx, resp, twirp.NewError(twirp.Canceled, "failed to read request: context canceled"))
+		return
+	}
+	if context.DeadlineExceeded == ctx.Err() {
+		s.writeError(ctx, resp, twirp.NewError(twirp.DeadlineExceeded, "failed to read request: deadline exceeded"))
+		return
+	}
+	s.writeError(ctx, resp, twirp.WrapError(malformedRequestError(msg), err))
+}
+
+// VhsServicePathPrefix is a convenience constant that may identify URL paths.
+// Should be used with caution, it only matches routes generated by Twirp Go clients,
+// with the default "/twirp" prefix and default CamelCase service and method names.
+// More info: https://twitchtv.github.io/twirp/docs/routing.html
+const VhsServicePathPrefix = "/twirp/pkg.cache.server.VhsService/"
+
+func (s *vhsServiceServer) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	ctx = ctxsetters.WithPackageName(ctx, "pkg.cache.server")
+	ctx = ctxsetters.WithServiceName(ctx, "VhsService")
+	ctx = ctxsetters.WithResponseWriter(ctx, resp)
+
+	var err error
+	ctx, err = callRequestReceived(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	if req.Method != "POST" {
+		msg := fmt.Sprintf("unsupported method %q (only POST is allowed)", req.Method)
+		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
+		return
+	}
+
+	// Verify path format: [<prefix>]/<package>.<Service>/<Method>
+	prefix, pkgService, method := parseTwirpPath(req.URL.Path)
+	if pkgService != "pkg.cache.server.VhsService" {
+		msg := fmt.Sprintf("no handler for path %q", req.URL.Path)
+		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
+		return
+	}
+	if prefix != s.pathPrefix {
+		msg := fmt.Sprintf("invalid path prefix %q, expected %q, on path %q", prefix, s.pathPrefix, req.URL.Path)
+		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
+		return
+	}
+
+	switch method {
+	case "Backup":
+		s.serveBackup(ctx, resp, req)
+		return
+	case "ListDevices":
+		s.serveListDevices(ctx, resp, req)
+		return
+	case "GetLatestBackup":
+		s.serveGetLatestBackup(ctx, resp, req)
+		return
+	case "GetBackupAt":
+		s.serveGetBackupAt(ctx, resp, req)
+		return
+	case "GetDeviceHistory":
+		s.serveGetDeviceHistory(ctx, resp, req)
+		return
+	case "DiffBackup":
+		s.serveDiffBackup(ctx, resp, req)
+		return
+	case "GetBackupStatus":
+		s.serveGetBackupStatus(ctx, resp, req)
+		return
+	case "GetSyncStatus":
+		s.serveGetSyncStatus(ctx, resp, req)
+		return
+	default:
+		msg := fmt.Sprintf("no handler for path %q", req.URL.Path)
+		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
+		return
+	}
+}
+
+func (s *vhsServiceServer) serveBackup(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveBackupJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveBackupProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *vhsServiceServer) serveBackupJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "Backup")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(BackupRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.VhsService.Backup
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *BackupRequest) (*BackupResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*BackupRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*BackupRequest) when calling interceptor")
+					}
+					return s.VhsService.Backup(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*BackupResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*BackupResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *BackupResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *BackupResponse and nil error while calling Backup. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *vhsServiceServer) serveBackupProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "Backup")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(BackupRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.VhsService.Backup
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *BackupRequest) (*BackupResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*BackupRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*BackupRequest) when calling interceptor")
+					}
+					return s.VhsService.Backup(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*BackupResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*BackupResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *BackupResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *BackupResponse and nil error while calling Backup. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *vhsServiceServer) serveListDevices(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveListDevicesJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveListDevicesProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *vhsServiceServer) serveListDevicesJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "ListDevices")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(ListDevicesRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.VhsService.ListDevices
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *ListDevicesRequest) (*ListDevicesResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ListDevicesRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ListDevicesRequest) when calling interceptor")
+					}
+					return s.VhsService.ListDevices(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ListDevicesResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ListDevicesResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *ListDevicesResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *ListDevicesResponse and nil error while calling ListDevices. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *vhsServiceServer) serveListDevicesProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "ListDevices")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(ListDevicesRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.VhsService.ListDevices
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *ListDevicesRequest) (*ListDevicesResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ListDevicesRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ListDevicesRequest) when calling interceptor")
+					}
+					return s.VhsService.ListDevices(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ListDevicesResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ListDevicesResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *ListDevicesResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *ListDevicesResponse and nil error while calling ListDevices. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *vhsServiceServer) serveGetLatestBackup(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveGetLatestBackupJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveGetLatestBackupProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *vhsServiceServer) serveGetLatestBackupJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetLatestBackup")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(GetLatestBackupRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.VhsService.GetLatestBackup
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *GetLatestBackupRequest) (*GetBackupResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetLatestBackupRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetLatestBackupRequest) when calling interceptor")
+					}
+					return s.VhsService.GetLatestBackup(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetBackupResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetBackupResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *GetBackupResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetBackupResponse and nil error while calling GetLatestBackup. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *vhsServiceServer) serveGetLatestBackupProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetLatestBackup")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(GetLatestBackupRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.VhsService.GetLatestBackup
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *GetLatestBackupRequest) (*GetBackupResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetLatestBackupRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetLatestBackupRequest) when calling interceptor")
+					}
+					return s.VhsService.GetLatestBackup(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetBackupResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetBackupResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *GetBackupResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetBackupResponse and nil error while calling GetLatestBackup. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *vhsServiceServer) serveGetBackupAt(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveGetBackupAtJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveGetBackupAtProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *vhsServiceServer) serveGetBackupAtJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetBackupAt")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(GetBackupAtRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.VhsService.GetBackupAt
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *GetBackupAtRequest) (*GetBackupResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetBackupAtRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetBackupAtRequest) when calling interceptor")
+					}
+					return s.VhsService.GetBackupAt(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetBackupResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetBackupResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *GetBackupResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetBackupResponse and nil error while calling GetBackupAt. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *vhsServiceServer) serveGetBackupAtProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetBackupAt")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(GetBackupAtRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.VhsService.GetBackupAt
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *GetBackupAtRequest) (*GetBackupResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetBackupAtRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetBackupAtRequest) when calling interceptor")
+					}
+					return s.VhsService.GetBackupAt(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetBackupResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetBackupResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *GetBackupResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetBackupResponse and nil error while calling GetBackupAt. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *vhsServiceServer) serveGetDeviceHistory(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveGetDeviceHistoryJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveGetDeviceHistoryProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *vhsServiceServer) serveGetDeviceHistoryJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetDeviceHistory")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(GetDeviceHistoryRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.VhsService.GetDeviceHistory
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *GetDeviceHistoryRequest) (*GetDeviceHistoryResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetDeviceHistoryRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetDeviceHistoryRequest) when calling interceptor")
+					}
+					return s.VhsService.GetDeviceHistory(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetDeviceHistoryResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetDeviceHistoryResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *GetDeviceHistoryResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetDeviceHistoryResponse and nil error while calling GetDeviceHistory. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *vhsServiceServer) serveGetDeviceHistoryProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetDeviceHistory")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(GetDeviceHistoryRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.VhsService.GetDeviceHistory
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *GetDeviceHistoryRequest) (*GetDeviceHistoryResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetDeviceHistoryRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetDeviceHistoryRequest) when calling interceptor")
+					}
+					return s.VhsService.GetDeviceHistory(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetDeviceHistoryResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetDeviceHistoryResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *GetDeviceHistoryResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetDeviceHistoryResponse and nil error while calling GetDeviceHistory. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *vhsServiceServer) serveDiffBackup(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveDiffBackupJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveDiffBackupProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *vhsServiceServer) serveDiffBackupJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "DiffBackup")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(DiffBackupRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.VhsService.DiffBackup
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *DiffBackupRequest) (*DiffBackupResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*DiffBackupRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*DiffBackupRequest) when calling interceptor")
+					}
+					return s.VhsService.DiffBackup(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*DiffBackupResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*DiffBackupResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *DiffBackupResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *DiffBackupResponse and nil error while calling DiffBackup. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *vhsServiceServer) serveDiffBackupProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "DiffBackup")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(DiffBackupRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.VhsService.DiffBackup
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *DiffBackupRequest) (*DiffBackupResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*DiffBackupRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*DiffBackupRequest) when calling interceptor")
+					}
+					return s.VhsService.DiffBackup(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*DiffBackupResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*DiffBackupResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *DiffBackupResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *DiffBackupResponse and nil error while calling DiffBackup. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *vhsServiceServer) serveGetBackupStatus(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveGetBackupStatusJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveGetBackupStatusProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
 	}
+}
 
-	// Using ReadOpt allows backwards and forwards compatibility with new options in the future
-	literalURLs := false
-	_ = clientOpts.ReadOpt("literalURLs", &literalURLs)
-	var pathPrefix string
-	if ok := clientOpts.ReadOpt("pathPrefix", &pathPrefix); !ok {
-		pathPrefix = "/twirp" // default prefix
+func (s *vhsServiceServer) serveGetBackupStatusJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetBackupStatus")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
 	}
 
-	// Build method URLs: <baseURL>[<prefix>]/<package>.<Service>/<Method>
-	serviceURL := sanitizeBaseURL(baseURL)
-	serviceURL += baseServicePath(pathPrefix, "pkg.cache.server", "VhsService")
-	urls := [1]string{
-		serviceURL + "Backup",
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
 	}
-
-	return &vhsServiceJSONClient{
-		client:      client,
-		urls:        urls,
-		interceptor: twirp.ChainInterceptors(clientOpts.Interceptors...),
-		opts:        clientOpts,
+	reqContent := new(GetBackupStatusRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
 	}
-}
 
-func (c *vhsServiceJSONClient) Backup(ctx context.Context, in *BackupRequest) (*BackupResponse, error) {
-	ctx = ctxsetters.WithPackageName(ctx, "pkg.cache.server")
-	ctx = ctxsetters.WithServiceName(ctx, "VhsService")
-	ctx = ctxsetters.WithMethodName(ctx, "Backup")
-	caller := c.callBackup
-	if c.interceptor != nil {
-		caller = func(ctx context.Context, req *BackupRequest) (*BackupResponse, error) {
-			resp, err := c.interceptor(
+	handler := s.VhsService.GetBackupStatus
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *GetBackupStatusRequest) (*GetBackupStatusResponse, error) {
+			resp, err := s.interceptor(
 				func(ctx context.Context, req interface{}) (interface{}, error) {
-					typedReq, ok := req.(*BackupRequest)
+					typedReq, ok := req.(*GetBackupStatusRequest)
 					if !ok {
-						return nil, twirp.InternalError("failed type assertion req.(*BackupRequest) when calling interceptor")
+						return nil, twirp.InternalError("failed type assertion req.(*GetBackupStatusRequest) when calling interceptor")
 					}
-					return c.callBackup(ctx, typedReq)
+					return s.VhsService.GetBackupStatus(ctx, typedReq)
 				},
 			)(ctx, req)
 			if resp != nil {
-				typedResp, ok := resp.(*BackupResponse)
+				typedResp, ok := resp.(*GetBackupStatusResponse)
 				if !ok {
-					return nil, twirp.InternalError("failed type assertion resp.(*BackupResponse) when calling interceptor")
+					return nil, twirp.InternalError("failed type assertion resp.(*GetBackupStatusResponse) when calling interceptor")
 				}
 				return typedResp, err
 			}
 			return nil, err
 		}
 	}
-	return caller(ctx, in)
-}
 
-func (c *vhsServiceJSONClient) callBackup(ctx context.Context, in *BackupRequest) (*BackupResponse, error) {
-	out := new(BackupResponse)
-	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[0], in, out)
+	// Call service method
+	var respContent *GetBackupStatusResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
 	if err != nil {
-		twerr, ok := err.(twirp.Error)
-		if !ok {
-			twerr = twirp.InternalErrorWith(err)
-		}
-		callClientError(ctx, c.opts.Hooks, twerr)
-		return nil, err
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetBackupStatusResponse and nil error while calling GetBackupStatus. nil responses are not supported"))
+		return
 	}
 
-	callClientResponseReceived(ctx, c.opts.Hooks)
-
-	return out, nil
-}
-
-// =========================
-// VhsService Server Handler
-// =========================
-
-type vhsServiceServer struct {
-	VhsService
-	interceptor      twirp.Interceptor
-	hooks            *twirp.ServerHooks
-	pathPrefix       string // prefix for routing
-	jsonSkipDefaults bool   // do not include unpopulated fields (default values) in the response
-	jsonCamelCase    bool   // JSON fields are serialized as lowerCamelCase rather than keeping the original proto names
-}
-
-// NewVhsServiceServer builds a TwirpServer that can be used as an http.Handler to handle
-// HTTP requests that are routed to the right method in the provided svc implementation.
-// The opts are twirp.ServerOption modifiers, for example twirp.WithServerHooks(hooks).
-func NewVhsServiceServer(svc VhsService, opts ...interface{}) TwirpServer {
-	serverOpts := newServerOpts(opts)
+	ctx = callResponsePrepared(ctx, s.hooks)
 
-	// Using ReadOpt allows backwards and forwards compatibility with new options in the future
-	jsonSkipDefaults := false
-	_ = serverOpts.ReadOpt("jsonSkipDefaults", &jsonSkipDefaults)
-	jsonCamelCase := false
-	_ = serverOpts.ReadOpt("jsonCamelCase", &jsonCamelCase)
-	var pathPrefix string
-	if ok := serverOpts.ReadOpt("pathPrefix", &pathPrefix); !ok {
-		pathPrefix = "/twirp" // default prefix
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
 	}
 
-	return &vhsServiceServer{
-		VhsService:       svc,
-		hooks:            serverOpts.Hooks,
-		interceptor:      twirp.ChainInterceptors(serverOpts.Interceptors...),
-		pathPrefix:       pathPrefix,
-		jsonSkipDefaults: jsonSkipDefaults,
-		jsonCamelCase:    jsonCamelCase,
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
 	}
+	callResponseSent(ctx, s.hooks)
 }
 
-// writeError writes an HTTP response with a valid Twirp error format, and triggers hooks.
-// If err is not a twirp.Error, it will get wrapped with twirp.InternalErrorWith(err)
-func (s *vhsServiceServer) writeError(ctx context.Context, resp http.ResponseWriter, err error) {
-	writeError(ctx, resp, err, s.hooks)
-}
+func (s *vhsServiceServer) serveGetBackupStatusProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetBackupStatus")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
 
-// handleRequestBodyError is used to handle error when the twirp server cannot read request
-func (s *vhsServiceServer) handleRequestBodyError(ctx context.Context, resp http.ResponseWriter, msg string, err error) {
-	if context.Canceled == ctx.Err() {
-		s.writeError(ctx, resp, twirp.NewError(twirp.Canceled, "failed to read request: context canceled"))
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
 		return
 	}
-	if context.DeadlineExceeded == ctx.Err() {
-		s.writeError(ctx, resp, twirp.NewError(twirp.DeadlineExceeded, "failed to read request: deadline exceeded"))
+	reqContent := new(GetBackupStatusRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
 		return
 	}
-	s.writeError(ctx, resp, twirp.WrapError(malformedRequestError(msg), err))
-}
 
-// VhsServicePathPrefix is a convenience constant that may identify URL paths.
-// Should be used with caution, it only matches routes generated by Twirp Go clients,
-// with the default "/twirp" prefix and default CamelCase service and method names.
-// More info: https://twitchtv.github.io/twirp/docs/routing.html
-const VhsServicePathPrefix = "/twirp/pkg.cache.server.VhsService/"
+	handler := s.VhsService.GetBackupStatus
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *GetBackupStatusRequest) (*GetBackupStatusResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetBackupStatusRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetBackupStatusRequest) when calling interceptor")
+					}
+					return s.VhsService.GetBackupStatus(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetBackupStatusResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetBackupStatusResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
 
-func (s *vhsServiceServer) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
-	ctx := req.Context()
-	ctx = ctxsetters.WithPackageName(ctx, "pkg.cache.server")
-	ctx = ctxsetters.WithServiceName(ctx, "VhsService")
-	ctx = ctxsetters.WithResponseWriter(ctx, resp)
+	// Call service method
+	var respContent *GetBackupStatusResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
 
-	var err error
-	ctx, err = callRequestReceived(ctx, s.hooks)
 	if err != nil {
 		s.writeError(ctx, resp, err)
 		return
 	}
-
-	if req.Method != "POST" {
-		msg := fmt.Sprintf("unsupported method %q (only POST is allowed)", req.Method)
-		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetBackupStatusResponse and nil error while calling GetBackupStatus. nil responses are not supported"))
 		return
 	}
 
-	// Verify path format: [<prefix>]/<package>.<Service>/<Method>
-	prefix, pkgService, method := parseTwirpPath(req.URL.Path)
-	if pkgService != "pkg.cache.server.VhsService" {
-		msg := fmt.Sprintf("no handler for path %q", req.URL.Path)
-		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
-		return
-	}
-	if prefix != s.pathPrefix {
-		msg := fmt.Sprintf("invalid path prefix %q, expected %q, on path %q", prefix, s.pathPrefix, req.URL.Path)
-		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
 		return
 	}
 
-	switch method {
-	case "Backup":
-		s.serveBackup(ctx, resp, req)
-		return
-	default:
-		msg := fmt.Sprintf("no handler for path %q", req.URL.Path)
-		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
-		return
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
 	}
+	callResponseSent(ctx, s.hooks)
 }
 
-func (s *vhsServiceServer) serveBackup(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+func (s *vhsServiceServer) serveGetSyncStatus(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
 	header := req.Header.Get("Content-Type")
 	i := strings.Index(header, ";")
 	if i == -1 {
@@ -334,9 +2287,9 @@ func (s *vhsServiceServer) serveBackup(ctx context.Context, resp http.ResponseWr
 	}
 	switch strings.TrimSpace(strings.ToLower(header[:i])) {
 	case "application/json":
-		s.serveBackupJSON(ctx, resp, req)
+		s.serveGetSyncStatusJSON(ctx, resp, req)
 	case "application/protobuf":
-		s.serveBackupProtobuf(ctx, resp, req)
+		s.serveGetSyncStatusProtobuf(ctx, resp, req)
 	default:
 		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
 		twerr := badRouteError(msg, req.Method, req.URL.Path)
@@ -344,9 +2297,9 @@ func (s *vhsServiceServer) serveBackup(ctx context.Context, resp http.ResponseWr
 	}
 }
 
-func (s *vhsServiceServer) serveBackupJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+func (s *vhsServiceServer) serveGetSyncStatusJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
 	var err error
-	ctx = ctxsetters.WithMethodName(ctx, "Backup")
+	ctx = ctxsetters.WithMethodName(ctx, "GetSyncStatus")
 	ctx, err = callRequestRouted(ctx, s.hooks)
 	if err != nil {
 		s.writeError(ctx, resp, err)
@@ -359,29 +2312,29 @@ func (s *vhsServiceServer) serveBackupJSON(ctx context.Context, resp http.Respon
 		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
 		return
 	}
-	reqContent := new(BackupRequest)
+	reqContent := new(GetSyncStatusRequest)
 	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
 	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
 		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
 		return
 	}
 
-	handler := s.VhsService.Backup
+	handler := s.VhsService.GetSyncStatus
 	if s.interceptor != nil {
-		handler = func(ctx context.Context, req *BackupRequest) (*BackupResponse, error) {
+		handler = func(ctx context.Context, req *GetSyncStatusRequest) (*GetSyncStatusResponse, error) {
 			resp, err := s.interceptor(
 				func(ctx context.Context, req interface{}) (interface{}, error) {
-					typedReq, ok := req.(*BackupRequest)
+					typedReq, ok := req.(*GetSyncStatusRequest)
 					if !ok {
-						return nil, twirp.InternalError("failed type assertion req.(*BackupRequest) when calling interceptor")
+						return nil, twirp.InternalError("failed type assertion req.(*GetSyncStatusRequest) when calling interceptor")
 					}
-					return s.VhsService.Backup(ctx, typedReq)
+					return s.VhsService.GetSyncStatus(ctx, typedReq)
 				},
 			)(ctx, req)
 			if resp != nil {
-				typedResp, ok := resp.(*BackupResponse)
+				typedResp, ok := resp.(*GetSyncStatusResponse)
 				if !ok {
-					return nil, twirp.InternalError("failed type assertion resp.(*BackupResponse) when calling interceptor")
+					return nil, twirp.InternalError("failed type assertion resp.(*GetSyncStatusResponse) when calling interceptor")
 				}
 				return typedResp, err
 			}
@@ -390,7 +2343,7 @@ func (s *vhsServiceServer) serveBackupJSON(ctx context.Context, resp http.Respon
 	}
 
 	// Call service method
-	var respContent *BackupResponse
+	var respContent *GetSyncStatusResponse
 	func() {
 		defer ensurePanicResponses(ctx, resp, s.hooks)
 		respContent, err = handler(ctx, reqContent)
@@ -401,7 +2354,7 @@ func (s *vhsServiceServer) serveBackupJSON(ctx context.Context, resp http.Respon
 		return
 	}
 	if respContent == nil {
-		s.writeError(ctx, resp, twirp.InternalError("received a nil *BackupResponse and nil error while calling Backup. nil responses are not supported"))
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetSyncStatusResponse and nil error while calling GetSyncStatus. nil responses are not supported"))
 		return
 	}
 
@@ -427,9 +2380,9 @@ func (s *vhsServiceServer) serveBackupJSON(ctx context.Context, resp http.Respon
 	callResponseSent(ctx, s.hooks)
 }
 
-func (s *vhsServiceServer) serveBackupProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+func (s *vhsServiceServer) serveGetSyncStatusProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
 	var err error
-	ctx = ctxsetters.WithMethodName(ctx, "Backup")
+	ctx = ctxsetters.WithMethodName(ctx, "GetSyncStatus")
 	ctx, err = callRequestRouted(ctx, s.hooks)
 	if err != nil {
 		s.writeError(ctx, resp, err)
@@ -441,28 +2394,28 @@ func (s *vhsServiceServer) serveBackupProtobuf(ctx context.Context, resp http.Re
 		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
 		return
 	}
-	reqContent := new(BackupRequest)
+	reqContent := new(GetSyncStatusRequest)
 	if err = proto.Unmarshal(buf, reqContent); err != nil {
 		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
 		return
 	}
 
-	handler := s.VhsService.Backup
+	handler := s.VhsService.GetSyncStatus
 	if s.interceptor != nil {
-		handler = func(ctx context.Context, req *BackupRequest) (*BackupResponse, error) {
+		handler = func(ctx context.Context, req *GetSyncStatusRequest) (*GetSyncStatusResponse, error) {
 			resp, err := s.interceptor(
 				func(ctx context.Context, req interface{}) (interface{}, error) {
-					typedReq, ok := req.(*BackupRequest)
+					typedReq, ok := req.(*GetSyncStatusRequest)
 					if !ok {
-						return nil, twirp.InternalError("failed type assertion req.(*BackupRequest) when calling interceptor")
+						return nil, twirp.InternalError("failed type assertion req.(*GetSyncStatusRequest) when calling interceptor")
 					}
-					return s.VhsService.Backup(ctx, typedReq)
+					return s.VhsService.GetSyncStatus(ctx, typedReq)
 				},
 			)(ctx, req)
 			if resp != nil {
-				typedResp, ok := resp.(*BackupResponse)
+				typedResp, ok := resp.(*GetSyncStatusResponse)
 				if !ok {
-					return nil, twirp.InternalError("failed type assertion resp.(*BackupResponse) when calling interceptor")
+					return nil, twirp.InternalError("failed type assertion resp.(*GetSyncStatusResponse) when calling interceptor")
 				}
 				return typedResp, err
 			}
@@ -471,7 +2424,7 @@ func (s *vhsServiceServer) serveBackupProtobuf(ctx context.Context, resp http.Re
 	}
 
 	// Call service method
-	var respContent *BackupResponse
+	var respContent *GetSyncStatusResponse
 	func() {
 		defer ensurePanicResponses(ctx, resp, s.hooks)
 		respContent, err = handler(ctx, reqContent)
@@ -482,7 +2435,7 @@ func (s *vhsServiceServer) serveBackupProtobuf(ctx context.Context, resp http.Re
 		return
 	}
 	if respContent == nil {
-		s.writeError(ctx, resp, twirp.InternalError("received a nil *BackupResponse and nil error while calling Backup. nil responses are not supported"))
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetSyncStatusResponse and nil error while calling GetSyncStatus. nil responses are not supported"))
 		return
 	}
 
@@ -1087,20 +3040,97 @@ func callClientError(ctx context.Context, h *twirp.ClientHooks, err twirp.Error)
 }
 
 var twirpFileDescriptor0 = []byte{
-	// 230 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x90, 0x31, 0x4b, 0x04, 0x31,
-	0x10, 0x85, 0x5d, 0xd1, 0xa8, 0xa3, 0x1e, 0xe7, 0x14, 0xb2, 0xd8, 0xb8, 0x6c, 0x75, 0x55, 0x56,
-	0x4e, 0xb0, 0x77, 0xb1, 0xb5, 0x89, 0x60, 0xa1, 0x55, 0xcc, 0x0d, 0x17, 0x59, 0x31, 0x31, 0x93,
-	0x1c, 0xf8, 0xef, 0x65, 0x93, 0xdb, 0x42, 0xe5, 0xba, 0x3c, 0xf2, 0xf8, 0xbe, 0x97, 0xc0, 0x45,
-	0xf0, 0xa6, 0x63, 0x0a, 0x9b, 0x77, 0x43, 0xd2, 0x07, 0x17, 0x1d, 0xce, 0xfd, 0xb0, 0x96, 0x46,
-	0x1b, 0x4b, 0x72, 0xbc, 0xa0, 0xd0, 0xde, 0x81, 0x78, 0xa0, 0xb1, 0x81, 0x08, 0x07, 0xd6, 0x71,
-	0xac, 0xab, 0xa6, 0x5a, 0x9c, 0xa8, 0x7c, 0xc6, 0x1a, 0x8e, 0xbc, 0xfe, 0xfe, 0x70, 0x7a, 0x55,
-	0xef, 0x37, 0xd5, 0xe2, 0x4c, 0x4d, 0xb1, 0xbd, 0x87, 0xf3, 0x5e, 0x9b, 0x21, 0x79, 0x45, 0x5f,
-	0x89, 0x38, 0xe2, 0x0d, 0x88, 0x55, 0x06, 0x65, 0xc0, 0xe9, 0xb2, 0x96, 0x7f, 0x5d, 0xb2, 0x88,
-	0xd4, 0xb6, 0xd7, 0xf6, 0x30, 0x9b, 0x10, 0xec, 0xdd, 0x27, 0xd3, 0xa8, 0xe3, 0x64, 0x0c, 0x31,
-	0x67, 0xc8, 0xb1, 0x9a, 0x22, 0x5e, 0x82, 0xe0, 0xa8, 0x63, 0xe2, 0xbc, 0xe3, 0x50, 0x6d, 0xd3,
-	0xf2, 0x15, 0xe0, 0xd9, 0xf2, 0x53, 0x79, 0x24, 0x3e, 0x82, 0x28, 0x44, 0xbc, 0xfe, 0x6f, 0xff,
-	0x35, 0xf7, 0xaa, 0xd9, 0x5d, 0x28, 0x63, 0xda, 0xbd, 0x7e, 0xfe, 0x32, 0xf3, 0xc3, 0xba, 0xdb,
-	0x58, 0xee, 0x4a, 0xe5, 0x4d, 0xe4, 0x6f, 0xbc, 0xfd, 0x09, 0x00, 0x00, 0xff, 0xff, 0x02, 0x34,
-	0xe5, 0x5f, 0x5b, 0x01, 0x00, 0x00,
+	// 1463 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x58, 0xcb, 0x72, 0x1b, 0xb7,
+	0x12, 0xf5, 0x0c, 0x1f, 0x22, 0x9b, 0x96, 0x4c, 0xc1, 0xb2, 0x3d, 0xc5, 0x2b, 0x5b, 0xba, 0x73,
+	0x7d, 0x7d, 0x65, 0x2f, 0x24, 0x5d, 0x25, 0x0b, 0x39, 0x95, 0x2c, 0x28, 0x89, 0xb6, 0xfc, 0xd0,
+	0x23, 0x43, 0xc9, 0x29, 0xbb, 0x52, 0xc5, 0x02, 0x87, 0xa0, 0x38, 0x16, 0x49, 0xd0, 0x03, 0x90,
+	0x15, 0xba, 0xbc, 0x48, 0x65, 0x99, 0x4d, 0x96, 0xa9, 0x7c, 0x43, 0x56, 0x59, 0x65, 0x9d, 0x2f,
+	0xc9, 0x0f, 0xe4, 0x23, 0x52, 0x78, 0xcc, 0x8b, 0x1c, 0xd2, 0x4a, 0x79, 0x37, 0x7d, 0xd0, 0x68,
+	0x9c, 0x3e, 0x68, 0x34, 0x40, 0xc2, 0xb2, 0x3f, 0x70, 0xb7, 0x18, 0xf1, 0x47, 0x9e, 0x4b, 0x36,
+	0x07, 0x3e, 0xe5, 0x14, 0x95, 0x07, 0x97, 0x17, 0x9b, 0x2e, 0x76, 0x3b, 0x64, 0x53, 0x0c, 0x10,
+	0xdf, 0xfe, 0xdd, 0x84, 0xfc, 0x01, 0x11, 0x2e, 0x08, 0x41, 0xb6, 0x43, 0x19, 0xb7, 0x8c, 0x75,
+	0x63, 0xa3, 0xe8, 0xc8, 0x6f, 0x64, 0xc1, 0xc2, 0x00, 0x8f, 0xbb, 0x14, 0xb7, 0x2c, 0x73, 0xdd,
+	0xd8, 0xb8, 0xee, 0x04, 0x26, 0x3a, 0x04, 0xc0, 0x9c, 0xfb, 0x5e, 0x73, 0xc8, 0x09, 0xb3, 0x32,
+	0xeb, 0x99, 0x8d, 0xd2, 0xce, 0xc6, 0xe6, 0x64, 0xfc, 0x4d, 0x15, 0x7b, 0xb3, 0x1a, 0xba, 0xd6,
+	0xfa, 0xdc, 0x1f, 0x3b, 0xb1, 0xb9, 0xe8, 0x4b, 0x28, 0xf4, 0x08, 0xc7, 0x2d, 0xcc, 0xb1, 0x95,
+	0x5d, 0x37, 0x36, 0x4a, 0x3b, 0xeb, 0xb3, 0xe2, 0x1c, 0x69, 0x3f, 0x27, 0x9c, 0x81, 0x76, 0xa1,
+	0x88, 0x7d, 0xee, 0xb5, 0xb1, 0xcb, 0x99, 0x95, 0x93, 0x34, 0x2a, 0xd3, 0xd3, 0xab, 0xda, 0xc5,
+	0x89, 0x9c, 0x2b, 0x5f, 0xc1, 0x8d, 0x09, 0x5a, 0xa8, 0x0c, 0x99, 0x4b, 0x32, 0xd6, 0x0a, 0x88,
+	0x4f, 0xb4, 0x02, 0xb9, 0x11, 0xee, 0x0e, 0x89, 0x4c, 0xbf, 0xe8, 0x28, 0xe3, 0x0b, 0x73, 0xd7,
+	0xb0, 0x77, 0xa1, 0x10, 0x44, 0x15, 0xd2, 0xf5, 0x71, 0x8f, 0x04, 0xd2, 0x89, 0xef, 0xd9, 0xd2,
+	0xd9, 0x3f, 0x98, 0xb0, 0x94, 0xcc, 0x07, 0xdd, 0x86, 0xfc, 0x88, 0xf4, 0x5b, 0xd4, 0xd7, 0x21,
+	0xb4, 0x85, 0x96, 0xc0, 0xa4, 0x4c, 0xaf, 0x6d, 0x52, 0x86, 0xee, 0x02, 0x50, 0xd6, 0x18, 0x11,
+	0x9f, 0x79, 0xb4, 0x6f, 0x65, 0x24, 0x5e, 0xa4, 0xec, 0x95, 0x02, 0x04, 0xdb, 0x1e, 0x6d, 0x91,
+	0xae, 0xd4, 0xb1, 0xe8, 0x28, 0x43, 0x04, 0x67, 0xc4, 0xf7, 0x70, 0xd7, 0xca, 0xa9, 0xe0, 0xca,
+	0x12, 0xac, 0x99, 0xc7, 0x89, 0x95, 0x57, 0xac, 0xc5, 0xb7, 0xc0, 0x7c, 0xda, 0x25, 0xd6, 0x82,
+	0xc2, 0xc4, 0xb7, 0xc0, 0x38, 0xbe, 0x60, 0x56, 0x61, 0x3d, 0x23, 0x30, 0xf1, 0x8d, 0xfe, 0x0d,
+	0xd7, 0x5d, 0xda, 0xed, 0x12, 0x97, 0x93, 0x56, 0x03, 0x73, 0xab, 0x28, 0xfd, 0x4b, 0x21, 0x56,
+	0xe5, 0x68, 0x15, 0x8a, 0xda, 0xa4, 0xbe, 0x05, 0x8a, 0x6a, 0x08, 0xd8, 0x0c, 0x16, 0xf7, 0xb0,
+	0x7b, 0x39, 0x1c, 0x38, 0xe4, 0xdd, 0x90, 0x30, 0x8e, 0xb6, 0x21, 0xdf, 0x92, 0xa2, 0x48, 0x09,
+	0x4a, 0x3b, 0xd6, 0xac, 0x22, 0x70, 0xb4, 0x1f, 0xda, 0x86, 0xac, 0x48, 0x50, 0xca, 0xb3, 0xb4,
+	0xb3, 0x3a, 0xed, 0xaf, 0x16, 0x38, 0xa2, 0x2d, 0xe2, 0x48, 0x4f, 0xfb, 0x1d, 0x2c, 0x05, 0x8b,
+	0xb2, 0x01, 0xed, 0x33, 0xb9, 0x4b, 0x6c, 0xe8, 0xba, 0x84, 0x31, 0xb9, 0x6c, 0xc1, 0x09, 0x4c,
+	0xa9, 0x1a, 0xc7, 0x7c, 0xa8, 0xe4, 0xcf, 0x39, 0xda, 0x42, 0xb7, 0x20, 0xff, 0x96, 0x36, 0x1b,
+	0x5e, 0x4b, 0xcb, 0x9f, 0x7b, 0x4b, 0x9b, 0xcf, 0x5a, 0xc2, 0xdd, 0xa5, 0xbd, 0x9e, 0xc7, 0xb5,
+	0xf6, 0xda, 0xb2, 0xb7, 0xe0, 0xf6, 0x53, 0xc2, 0xd5, 0xaa, 0x75, 0x19, 0x21, 0x48, 0x38, 0x0a,
+	0x64, 0xc4, 0x02, 0xd9, 0x7f, 0x1a, 0x70, 0x67, 0x6a, 0x86, 0x66, 0x9b, 0x3e, 0x25, 0x3c, 0xb9,
+	0x66, 0xec, 0xe4, 0x6e, 0x43, 0x4e, 0x10, 0x26, 0x92, 0xe5, 0x52, 0xda, 0x99, 0x78, 0x4e, 0x9b,
+	0x22, 0x3c, 0x71, 0x94, 0xe3, 0xac, 0x0c, 0x44, 0x51, 0x11, 0xdf, 0xa7, 0xbe, 0xae, 0x1e, 0x65,
+	0xa0, 0x0a, 0x14, 0x30, 0xe7, 0xa4, 0x37, 0xe0, 0x4c, 0x16, 0x50, 0xce, 0x09, 0x6d, 0x51, 0xa5,
+	0xc3, 0x41, 0x0b, 0xeb, 0xd2, 0x50, 0xa5, 0x54, 0xd4, 0x48, 0x95, 0xdb, 0xb7, 0x61, 0xe5, 0x29,
+	0xe1, 0xf5, 0x71, 0xdf, 0x4d, 0x08, 0x62, 0x7f, 0x6f, 0xc2, 0xad, 0x89, 0x01, 0x9d, 0xf7, 0x0a,
+	0xe4, 0x70, 0x87, 0x60, 0x95, 0x76, 0xce, 0x51, 0x86, 0x20, 0xdc, 0x24, 0x1d, 0xaf, 0xdf, 0x0a,
+	0x76, 0x48, 0x59, 0xa2, 0x36, 0xbb, 0x98, 0xf1, 0x86, 0xe6, 0xa3, 0xf7, 0xa9, 0x24, 0xb0, 0xaa,
+	0x82, 0x42, 0x97, 0x60, 0xef, 0xb3, 0x91, 0x4b, 0x5d, 0x41, 0xc2, 0xa5, 0x4f, 0xbe, 0x8b, 0xa2,
+	0xa8, 0xec, 0x4b, 0x02, 0x0b, 0xa2, 0xdc, 0x05, 0x90, 0x51, 0x94, 0x3c, 0xea, 0x18, 0x15, 0x05,
+	0x52, 0x93, 0x12, 0xfd, 0x1f, 0x56, 0x5c, 0x41, 0xdf, 0x1d, 0x72, 0x6f, 0x44, 0x1a, 0x6d, 0xec,
+	0x75, 0x87, 0x3e, 0x61, 0x52, 0x90, 0x9c, 0x73, 0x33, 0x36, 0xf6, 0x44, 0x0f, 0xd9, 0x7f, 0x18,
+	0xb0, 0xa8, 0xaa, 0xbc, 0x3e, 0xec, 0xf5, 0xb0, 0x3f, 0x4e, 0xed, 0xca, 0x6b, 0x50, 0x52, 0x47,
+	0xa0, 0xc1, 0xc7, 0x83, 0xa0, 0x35, 0x81, 0x82, 0xce, 0xc6, 0x03, 0x22, 0x1c, 0x24, 0xb1, 0xa6,
+	0x2c, 0x22, 0x2d, 0x80, 0xe4, 0xaa, 0xca, 0xea, 0x13, 0x7b, 0xee, 0xea, 0x64, 0xcf, 0x2d, 0xc6,
+	0xfa, 0xaa, 0xfd, 0x97, 0x01, 0xe8, 0xa5, 0xc7, 0xb8, 0x9a, 0x1e, 0x96, 0xfb, 0x04, 0x69, 0x63,
+	0x8a, 0x74, 0xd4, 0x03, 0xcd, 0x94, 0x1e, 0x98, 0x99, 0xd1, 0x03, 0xb3, 0x33, 0x7b, 0x60, 0x2e,
+	0xde, 0x03, 0x3f, 0xa5, 0xd7, 0x25, 0x1a, 0x59, 0x71, 0xb2, 0x91, 0x9d, 0xc2, 0xcd, 0x44, 0xb6,
+	0xba, 0x64, 0x1f, 0xc3, 0x82, 0xca, 0x4d, 0x34, 0x16, 0x71, 0x2b, 0xad, 0xcd, 0x12, 0x58, 0xef,
+	0xb4, 0x13, 0xf8, 0xdb, 0x87, 0xb2, 0x65, 0xbc, 0xc4, 0x9c, 0x04, 0xfb, 0x15, 0x68, 0x98, 0x56,
+	0x0c, 0xe2, 0x20, 0x6a, 0xed, 0xb5, 0x70, 0xa1, 0x6d, 0xff, 0x68, 0x00, 0x0a, 0x7b, 0x49, 0x95,
+	0xcf, 0x0b, 0x73, 0x0f, 0x8a, 0xdc, 0xeb, 0x11, 0xc6, 0x71, 0x6f, 0xa0, 0xe2, 0x1c, 0x5e, 0x73,
+	0x22, 0x08, 0x59, 0x61, 0x77, 0xc8, 0xe8, 0x41, 0x6d, 0x27, 0x08, 0x64, 0x93, 0x04, 0xf6, 0x00,
+	0x0a, 0x3e, 0x19, 0x79, 0x62, 0x63, 0xec, 0x9f, 0x0d, 0x58, 0x0e, 0xc9, 0x84, 0x3a, 0xfd, 0xf3,
+	0xb6, 0x1f, 0xf5, 0x29, 0x33, 0xd1, 0xa7, 0x56, 0xe3, 0x19, 0xe8, 0xab, 0x31, 0xe2, 0x3f, 0x87,
+	0xa5, 0xfd, 0x9b, 0x6a, 0xb9, 0x6a, 0x9d, 0x43, 0x8f, 0x71, 0xea, 0x8f, 0xe7, 0x69, 0xb5, 0x02,
+	0x39, 0xe6, 0xf5, 0xdd, 0xf0, 0x51, 0x20, 0x0d, 0x81, 0x0e, 0xfb, 0xdc, 0xeb, 0x06, 0xf7, 0x82,
+	0x34, 0xd0, 0xbf, 0xa0, 0x38, 0xc0, 0x17, 0xa4, 0xc1, 0xbc, 0xf7, 0x44, 0x2e, 0x9c, 0x73, 0x0a,
+	0x02, 0xa8, 0x7b, 0xef, 0x89, 0x28, 0x65, 0x39, 0xc8, 0xe9, 0x25, 0xe9, 0xeb, 0x82, 0x95, 0xee,
+	0x67, 0x02, 0x48, 0x70, 0xce, 0x4f, 0x70, 0xfe, 0xc5, 0x80, 0xeb, 0x9a, 0xaa, 0x7a, 0xbb, 0x44,
+	0xb2, 0x18, 0xb3, 0x65, 0x31, 0x27, 0x65, 0xb1, 0x60, 0xa1, 0x47, 0x18, 0xc3, 0x17, 0x44, 0xd3,
+	0x0e, 0x4c, 0x11, 0x0f, 0x0f, 0x79, 0x87, 0xfa, 0xc1, 0x75, 0xa0, 0x2c, 0xd1, 0x17, 0xf5, 0x43,
+	0x46, 0xe5, 0x24, 0x58, 0x67, 0x9c, 0x92, 0xc6, 0x44, 0x5a, 0xf6, 0x07, 0xb0, 0xa6, 0xe5, 0xd4,
+	0xfb, 0xbd, 0x0b, 0x0b, 0xa4, 0xcf, 0x7d, 0x2f, 0x3c, 0x17, 0xf7, 0xa6, 0x37, 0x3c, 0x9e, 0x97,
+	0x13, 0xb8, 0xa3, 0x07, 0x70, 0x43, 0x36, 0xe4, 0x98, 0x62, 0x2a, 0x9d, 0x45, 0x01, 0x9f, 0x06,
+	0xaa, 0xd9, 0xaf, 0xa2, 0x4b, 0x5e, 0x55, 0x5e, 0xb2, 0xb6, 0x8d, 0x79, 0xb5, 0x6d, 0x26, 0x6b,
+	0x3b, 0x51, 0xbf, 0xbf, 0x1a, 0xb0, 0x7c, 0xe0, 0xb5, 0xdb, 0x1f, 0x3f, 0x92, 0x9f, 0x43, 0xb6,
+	0xed, 0xd3, 0x9e, 0x65, 0xce, 0xea, 0xac, 0x49, 0x7e, 0x8e, 0xf4, 0x46, 0xdb, 0x60, 0x72, 0x6a,
+	0x65, 0xae, 0x38, 0xc7, 0xe4, 0x74, 0x6e, 0x4d, 0xb7, 0x01, 0xc5, 0xc9, 0x6a, 0xf5, 0xd7, 0xa0,
+	0x24, 0xd6, 0x6a, 0x24, 0x2a, 0x05, 0x04, 0xb4, 0x2f, 0x11, 0x51, 0xae, 0x9c, 0x36, 0x12, 0xe7,
+	0xab, 0xc0, 0xa9, 0x1e, 0x44, 0x90, 0x6d, 0x79, 0xed, 0xb6, 0xae, 0x14, 0xf9, 0xfd, 0xe8, 0x31,
+	0x40, 0xf4, 0xcc, 0x42, 0xb7, 0x60, 0x79, 0xaf, 0xba, 0xff, 0xe2, 0xfc, 0xb4, 0x71, 0x74, 0x72,
+	0x50, 0x6b, 0x54, 0xeb, 0xaf, 0x8f, 0xf7, 0xcb, 0xd7, 0xd0, 0x0a, 0x94, 0xe3, 0xb0, 0x44, 0x8d,
+	0x47, 0x1f, 0xa0, 0x10, 0xbc, 0x41, 0xc4, 0xc4, 0xe7, 0x27, 0x7b, 0x8d, 0xfa, 0x59, 0xf5, 0xac,
+	0xd6, 0x38, 0x3f, 0x7e, 0x71, 0x7c, 0xf2, 0xcd, 0xb1, 0x9a, 0x18, 0xc1, 0x5f, 0x9f, 0xd7, 0xce,
+	0x6b, 0x07, 0x65, 0x03, 0xdd, 0x81, 0x9b, 0x11, 0xba, 0x7f, 0x72, 0x74, 0xf4, 0xec, 0xec, 0xac,
+	0x76, 0x50, 0x36, 0x93, 0xee, 0xa7, 0xe7, 0xf5, 0xc3, 0xda, 0x41, 0x39, 0x93, 0x44, 0x9f, 0x54,
+	0x9f, 0xbd, 0xac, 0x1d, 0x94, 0xb3, 0x3b, 0x3f, 0xe5, 0x01, 0x5e, 0x75, 0x58, 0x5d, 0xfd, 0x40,
+	0x42, 0x47, 0x90, 0xd7, 0x77, 0xe3, 0xda, 0x6c, 0xed, 0xe5, 0x96, 0x57, 0xe6, 0x6c, 0x8e, 0x92,
+	0xd9, 0xbe, 0x86, 0xbe, 0x85, 0x52, 0xec, 0x56, 0x40, 0xf7, 0xa7, 0xa7, 0x4c, 0x5f, 0x91, 0x95,
+	0xff, 0x7e, 0xc4, 0x2b, 0x8c, 0xde, 0x84, 0x1b, 0x13, 0x37, 0x04, 0x4a, 0xf9, 0xed, 0x95, 0x7e,
+	0x89, 0x54, 0xfe, 0x93, 0xea, 0x39, 0x95, 0xc1, 0x1b, 0x28, 0xc5, 0xae, 0x8e, 0xb4, 0x0c, 0xa6,
+	0x6f, 0x96, 0xab, 0xc6, 0xbe, 0x84, 0xf2, 0x64, 0x83, 0x40, 0x0f, 0x53, 0xa7, 0xa6, 0xf5, 0xe4,
+	0xca, 0xa3, 0xab, 0xb8, 0x86, 0x8b, 0xbd, 0x06, 0x88, 0x4e, 0x02, 0x4a, 0x61, 0x38, 0x75, 0xa8,
+	0x2b, 0xf7, 0xe7, 0x3b, 0x85, 0xa1, 0x3b, 0x72, 0x1f, 0xe2, 0x4f, 0xf5, 0x19, 0xfb, 0x90, 0xf2,
+	0xfe, 0xaf, 0x3c, 0xbc, 0x82, 0x67, 0x6c, 0xc7, 0x17, 0x13, 0x4f, 0x63, 0xf4, 0x20, 0x75, 0xf6,
+	0xd4, 0xa3, 0xba, 0xf2, 0xbf, 0x8f, 0xfa, 0x05, 0x6b, 0xec, 0x95, 0xdf, 0x2c, 0x0d, 0x2e, 0x2f,
+	0xb6, 0x46, 0x1d, 0xb6, 0xa5, 0x3c, 0x9b, 0x79, 0xf9, 0xb7, 0xc1, 0x67, 0x7f, 0x0f, 0x00, 0xdb,
+	0xbf, 0x56, 0xe6, 0x4b, 0x10, 0x00, 0x00,
 }
@@ -0,0 +1,227 @@
+// Package redact removes secrets such as passwords, keys and SNMP communities from device
+// output before it is stored.
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"vhs/devices"
+)
+
+// Placeholder replaces every secret in ModePlaceholder.
+const Placeholder = "REDACTED"
+
+// Modes decide what secrets are replaced by.
+const (
+	// ModePlaceholder replaces every secret by Placeholder.
+	ModePlaceholder = "placeholder"
+	// ModeFingerprint replaces every secret by a keyed fingerprint such as
+	// <secret:3fa9c1>, so that diffs show when a secret changed without revealing it.
+	ModeFingerprint = "fingerprint"
+)
+
+// MinKeyLength is the shortest fingerprint key accepted, in bytes.
+const MinKeyLength = 16
+
+// fingerprintLength is the number of hex digits of the HMAC kept in a fingerprint. It is
+// enough to tell a rotated secret from the old one, and too little to guess it from.
+const fingerprintLength = 6
+
+// redacted matches secrets that were redacted already, e.g. by the collector.
+var redacted = regexp.MustCompile(fmt.Sprintf(`^(?:%s|<secret:[0-9a-f]{%d}>)$`, Placeholder, fingerprintLength))
+
+// secretGroup names the capture group holding the secret in a rule's pattern.
+const secretGroup = "secret"
+
+// sp matches the blanks separating a keyword from its value, however many there are.
+const sp = `[ \t]+`
+
+// value matches a secret value, optionally quoted as Junos does, without a trailing ";".
+const value = `"?(?P<secret>[^\s;"]+)"?`
+
+// Rule redacts the secrets its pattern matches.
+type Rule struct {
+	// Name describes what the rule redacts, e.g. "snmp-community".
+	Name string `yaml:"name"`
+	// Vendors limits the rule to devices whose metadata names one of these vendors,
+	// compared case-insensitively. Rules apply to every device when it is empty, and every
+	// rule applies to devices without a vendor.
+	Vendors []string `yaml:"vendors,omitempty"`
+	// Pattern is a regular expression matched against the whole output, so it may span
+	// lines. Only the capture group named "secret" is redacted, or the whole match when the
+	// pattern has none.
+	Pattern string `yaml:"pattern"`
+}
+
+// DefaultRules redact the secrets found in Cisco, Arista and Juniper configurations, along
+// with PEM keys and certificates.
+func DefaultRules() []Rule {
+	ciscoLike := []string{"cisco", "arista"}
+	return []Rule{
+		// Cisco type 0, 5, 7, 8 and 9 passwords and secrets, and Junos secrets.
+		{Name: "password", Pattern: `(?im)(?:^|[ \t])(?:password|secret|encrypted-password)(?:` + sp + `[0-9])?` + sp + value},
+		{Name: "snmp-community", Pattern: `(?im)^\s*snmp-server` + sp + `community` + sp + value},
+		{Name: "snmp-user", Pattern: `(?i)\b(?:auth` + sp + `(?:md5|sha\S*)|priv` + sp + `(?:des|3des|aes(?:` + sp + `[0-9]+)?))` + sp + value},
+		{Name: "pre-shared-key", Pattern: `(?i)\bpre-shared-key(?:` + sp + `(?:local|remote))?(?:` + sp + `(?:[0-9]|ascii-text|hexadecimal))?` + sp + value},
+		{Name: "private-key", Pattern: `(?s)-----BEGIN (?:[A-Z]+ )*PRIVATE KEY-----(?P<secret>.+?)-----END (?:[A-Z]+ )*PRIVATE KEY-----`},
+		{Name: "certificate", Pattern: `(?s)-----BEGIN CERTIFICATE-----(?P<secret>.+?)-----END CERTIFICATE-----`},
+		{Name: "tacacs-radius-key", Vendors: ciscoLike, Pattern: `(?im)^\s*(?:tacacs-server|radius-server)\b.*?\bkey(?:` + sp + `[0-9])?` + sp + value},
+		{Name: "server-key", Vendors: ciscoLike, Pattern: `(?im)^[ \t]+(?:server-)?key(?:` + sp + `[0-9])?` + sp + value + `[ \t]*$`},
+		{Name: "key-string", Vendors: ciscoLike, Pattern: `(?i)\bkey-string(?:` + sp + `[0-9])?` + sp + value},
+		{Name: "isakmp-key", Vendors: ciscoLike, Pattern: `(?im)^\s*crypto` + sp + `isakmp` + sp + `key(?:` + sp + `[0-9])?` + sp + value},
+		{Name: "certificate-block", Vendors: ciscoLike, Pattern: `(?ms)^[ \t]*certificate(?:` + sp + `(?:ca|self-signed))?` + sp + `\S+[ \t]*\n(?P<secret>.*?)^[ \t]*quit[ \t]*$`},
+		{Name: "junos-secret", Vendors: []string{"juniper"}, Pattern: `\$9\$[^\s;"]+`},
+		{Name: "junos-community", Vendors: []string{"juniper"}, Pattern: `(?m)^[ \t]*community` + sp + value + sp + `\{`},
+	}
+}
+
+// Redactor applies rules to the output of devices.
+type Redactor struct {
+	rules []rule
+	// key makes the Redactor replace secrets by fingerprints when it is set.
+	key []byte
+}
+
+type rule struct {
+	name    string
+	vendors []string
+	pattern *regexp.Regexp
+	// group is the index of the capture group holding the secret, zero for the whole match.
+	group int
+}
+
+// NewFingerprintRedactor compiles rules into a Redactor replacing every secret by its
+// HMAC-SHA256 under key, shortened to a fingerprint. The same secret always gets the same
+// fingerprint under the same key.
+func NewFingerprintRedactor(rules []Rule, key []byte) (*Redactor, error) {
+	if len(key) < MinKeyLength {
+		return nil, fmt.Errorf("fingerprint key must be at least %d bytes long", MinKeyLength)
+	}
+	r, err := NewRedactor(rules)
+	if err != nil {
+		return nil, err
+	}
+	r.key = append([]byte(nil), key...)
+	return r, nil
+}
+
+// NewRedactor compiles rules into a Redactor replacing every secret by Placeholder.
+func NewRedactor(rules []Rule) (*Redactor, error) {
+	r := &Redactor{}
+	var errs []error
+	for i, ru := range rules {
+		compiled, err := compileRule(ru)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rule %d: %w", i+1, err))
+			continue
+		}
+		r.rules = append(r.rules, compiled)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func compileRule(r Rule) (rule, error) {
+	if r.Name == "" {
+		return rule{}, errors.New("name must be set")
+	}
+	if r.Pattern == "" {
+		return rule{}, errors.New("pattern must be set")
+	}
+	pattern, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		return rule{}, fmt.Errorf("invalid pattern: %w", err)
+	}
+	group := pattern.SubexpIndex(secretGroup)
+	if group < 0 {
+		group = 0
+	}
+	return rule{name: r.Name, vendors: r.Vendors, pattern: pattern, group: group}, nil
+}
+
+// RedactDevice redacts a device's configuration and artifacts by the rules matching its
+// vendor. The device passed in is not modified.
+func (r *Redactor) RedactDevice(device devices.Device) devices.Device {
+	vendor := device.Metadata.Vendor
+	device.Payload = r.Redact(vendor, device.Payload)
+	if device.Artifacts != nil {
+		artifacts := make([]devices.Artifact, len(device.Artifacts))
+		for i, artifact := range device.Artifacts {
+			artifacts[i] = devices.Artifact{Name: artifact.Name, Payload: r.Redact(vendor, artifact.Payload)}
+		}
+		device.Artifacts = artifacts
+	}
+	return device
+}
+
+// Redact replaces every secret the rules matching vendor find in output by Placeholder, or
+// by its fingerprint.
+func (r *Redactor) Redact(vendor string, output []byte) []byte {
+	for _, ru := range r.rules {
+		if ru.matches(vendor) {
+			output = ru.redact(output, r.replacement)
+		}
+	}
+	return output
+}
+
+// replacement returns what replaces secret.
+func (r *Redactor) replacement(secret []byte) string {
+	if r.key == nil {
+		return Placeholder
+	}
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write(secret)
+	return "<secret:" + hex.EncodeToString(mac.Sum(nil))[:fingerprintLength] + ">"
+}
+
+func (r rule) matches(vendor string) bool {
+	if len(r.vendors) == 0 || vendor == "" {
+		return true
+	}
+	for _, v := range r.vendors {
+		if strings.EqualFold(v, vendor) {
+			return true
+		}
+	}
+	return false
+}
+
+// redact replaces the secrets in output by what replace returns for them. Line breaks
+// around a multi-line secret are kept, so the lines following it stay where they were.
+// Secrets that were redacted already are left alone.
+func (r rule) redact(output []byte, replace func(secret []byte) string) []byte {
+	matches := r.pattern.FindAllSubmatchIndex(output, -1)
+	if matches == nil {
+		return output
+	}
+	result := make([]byte, 0, len(output))
+	last := 0
+	for _, match := range matches {
+		start, end := match[2*r.group], match[2*r.group+1]
+		if start < 0 {
+			continue
+		}
+		secret := output[start:end]
+		if redacted.Match(secret) {
+			continue
+		}
+		result = append(result, output[last:start]...)
+		if strings.HasPrefix(string(secret), "\n") {
+			result = append(result, '\n')
+		}
+		result = append(result, replace(secret)...)
+		if len(secret) > 1 && strings.HasSuffix(string(secret), "\n") {
+			result = append(result, '\n')
+		}
+		last = end
+	}
+	return append(result, output[last:]...)
+}
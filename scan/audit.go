@@ -0,0 +1,120 @@
+package scan
+
+import (
+	"fmt"
+	"sort"
+	"time"
+	"vhs/storage"
+)
+
+// auditPageSize is how many history entries Audit reads at a time.
+const auditPageSize = 100
+
+// Result lists the findings in one stored revision of a device's configuration or
+// artifact.
+type Result struct {
+	Device    string
+	Artifact  string
+	Commit    string
+	Timestamp time.Time
+	Findings  []Finding
+}
+
+// Audit scans every stored revision of the configurations and artifacts in store, and
+// returns the revisions with findings by device, newest first. A store that is a
+// storage.RevisionWalker is audited over its whole history, deprecated and removed devices
+// included; other stores only for the devices they list.
+func Audit(store storage.Store, s *Scanner) ([]Result, error) {
+	walker, ok := store.(storage.RevisionWalker)
+	if !ok {
+		return auditListed(store, s)
+	}
+	var results []Result
+	err := walker.WalkRevisions(func(revision storage.Revision) error {
+		device, artifact := storage.SplitName(revision.Device)
+		if result, found := scanRevision(s, device, artifact, revision.Commit, revision.Timestamp, revision.Payload); found {
+			results = append(results, result)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the stored revisions: %w", err)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Device != results[j].Device {
+			return results[i].Device < results[j].Device
+		}
+		return results[i].Artifact < results[j].Artifact
+	})
+	return results, nil
+}
+
+// auditListed audits the devices store lists.
+func auditListed(store storage.Store, s *Scanner) ([]Result, error) {
+	infos, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	var results []Result
+	for _, info := range infos {
+		found, err := auditName(store, s, info.Name, "")
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, found...)
+		for _, artifact := range info.Artifacts {
+			found, err := auditName(store, s, info.Name, artifact)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, found...)
+		}
+	}
+	return results, nil
+}
+
+// auditName scans every revision of a device's configuration, or of one of its artifacts.
+func auditName(store storage.Store, s *Scanner, device string, artifact string) ([]Result, error) {
+	name := device
+	if artifact != "" {
+		name = storage.ArtifactName(device, artifact)
+	}
+	var results []Result
+	for skip := 0; ; skip += auditPageSize {
+		entries, more, err := store.History(name, storage.HistoryOptions{Skip: skip, Limit: auditPageSize})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the history of %s: %w", name, err)
+		}
+		for _, entry := range entries {
+			revision, err := store.Get(name, storage.Query{Revision: entry.Commit})
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s at %s: %w", name, entry.Commit, err)
+			}
+			if result, found := scanRevision(s, device, artifact, entry.Commit, entry.Timestamp, revision.Payload); found {
+				results = append(results, result)
+			}
+		}
+		if !more {
+			return results, nil
+		}
+	}
+}
+
+// scanRevision scans one revision of a device's configuration, or of one of its artifacts,
+// and reports whether it has findings.
+func scanRevision(s *Scanner, device string, artifact string, commit string, timestamp time.Time, payload []byte) (Result, bool) {
+	findings := s.Scan(payload)
+	if len(findings) == 0 {
+		return Result{}, false
+	}
+	for i := range findings {
+		findings[i].Artifact = artifact
+	}
+	return Result{
+		Device:    device,
+		Artifact:  artifact,
+		Commit:    commit,
+		Timestamp: timestamp,
+		Findings:  findings,
+	}, true
+}
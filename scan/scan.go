@@ -0,0 +1,239 @@
+// Package scan looks for secrets that slipped through redaction: known token formats and
+// high-entropy strings. Backups with findings are quarantined instead of committed, and
+// Audit runs the same checks over the stored history.
+package scan
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"vhs/devices"
+)
+
+// EntropyRule names the findings of high-entropy strings.
+const EntropyRule = "high-entropy"
+
+// Rule flags every match of Pattern as a possible secret.
+type Rule struct {
+	Name    string `yaml:"name"`
+	Pattern string `yaml:"pattern"`
+}
+
+// Entropy flags strings of at least MinLength characters whose Shannon entropy, in bits
+// per character, is at least Threshold. A zero Threshold turns the check off.
+type Entropy struct {
+	MinLength int     `yaml:"min_length"`
+	Threshold float64 `yaml:"threshold"`
+}
+
+// DefaultRules returns the rules for well-known credential formats.
+func DefaultRules() []Rule {
+	return []Rule{
+		{Name: "private-key", Pattern: `-----BEGIN (?:[A-Z]+ )*PRIVATE KEY-----\r?\n[A-Za-z0-9+/=]{16,}`},
+		{Name: "aws-access-key", Pattern: `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`},
+		{Name: "github-token", Pattern: `\bgh[pousr]_[A-Za-z0-9]{36,}\b`},
+		{Name: "slack-token", Pattern: `\bxox[abprs]-[A-Za-z0-9-]{10,}`},
+		{Name: "jwt", Pattern: `\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`},
+		// Unix crypt hashes, as used for Cisco type 5, 8 and 9 secrets and Junos passwords.
+		{Name: "password-hash", Pattern: `\$(?:1|5|6|8|9|2[aby]?)\$[./A-Za-z0-9]{2,}\$[./A-Za-z0-9]{8,}`},
+		{Name: "junos-secret", Pattern: `\$9\$[^\s;"]{8,}`},
+	}
+}
+
+// DefaultEntropy returns the entropy check used unless configured otherwise. It flags
+// random base64 strings of 20 characters and more, but neither hex strings, which top
+// out at 4 bits per character, nor interface names and descriptions.
+func DefaultEntropy() Entropy {
+	return Entropy{MinLength: 20, Threshold: 4.0}
+}
+
+// Finding is a possible secret. It locates the secret without repeating it.
+type Finding struct {
+	// Rule is the name of the rule that matched, or EntropyRule.
+	Rule string
+	// Artifact names the artifact the secret is in; it is empty for the configuration.
+	Artifact string
+	// Line is the 1-based line the secret starts on.
+	Line int
+}
+
+func (f Finding) String() string {
+	where := "configuration"
+	if f.Artifact != "" {
+		where = "artifact " + f.Artifact
+	}
+	return fmt.Sprintf("%s on line %d of %s", f.Rule, f.Line, where)
+}
+
+// Scanner finds possible secrets in the output of devices.
+type Scanner struct {
+	rules   []rule
+	allow   []*regexp.Regexp
+	entropy Entropy
+}
+
+type rule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// tokenPattern splits lines into the candidates of the entropy check. Dashes and dots
+// separate tokens, so hyphenated names and version numbers are not taken for secrets.
+var tokenPattern = regexp.MustCompile(`[A-Za-z0-9+/=_]+`)
+
+var hexPattern = regexp.MustCompile(`^[0-9A-Fa-f]+$`)
+
+// publicKeyPattern matches SSH public keys, which look random but are not secret. Lines
+// holding one are never flagged.
+var publicKeyPattern = regexp.MustCompile(`\b(?:ssh-(?:rsa|dss|ed25519)|ecdsa-sha2-nistp(?:256|384|521)) AAAA[A-Za-z0-9+/]`)
+
+// NewScanner compiles rules into a Scanner. Lines matching any of the allow patterns, and
+// lines holding SSH public keys, are never flagged.
+func NewScanner(rules []Rule, allow []string, entropy Entropy) (*Scanner, error) {
+	s := &Scanner{entropy: entropy}
+	var errs []error
+	for i, r := range rules {
+		if r.Name == "" {
+			errs = append(errs, fmt.Errorf("rule %d: name must be set", i+1))
+			continue
+		}
+		if r.Pattern == "" {
+			errs = append(errs, fmt.Errorf("rule %d: pattern must be set", i+1))
+			continue
+		}
+		pattern, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rule %d: invalid pattern: %w", i+1, err))
+			continue
+		}
+		s.rules = append(s.rules, rule{name: r.Name, pattern: pattern})
+	}
+	for i, a := range allow {
+		pattern, err := regexp.Compile(a)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("allow %d: invalid pattern: %w", i+1, err))
+			continue
+		}
+		s.allow = append(s.allow, pattern)
+	}
+	if entropy.Threshold < 0 {
+		errs = append(errs, fmt.Errorf("entropy.threshold: must not be negative, got %g", entropy.Threshold))
+	}
+	if entropy.Threshold > 0 && entropy.MinLength < 1 {
+		errs = append(errs, fmt.Errorf("entropy.min_length: must be at least 1, got %d", entropy.MinLength))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ScanDevice returns the findings in a device's configuration and artifacts.
+func (s *Scanner) ScanDevice(device devices.Device) []Finding {
+	findings := s.Scan(device.Payload)
+	for _, artifact := range device.Artifacts {
+		for _, f := range s.Scan(artifact.Payload) {
+			f.Artifact = artifact.Name
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// Scan returns the findings in output, ordered by line. A line is reported at most once
+// per rule.
+func (s *Scanner) Scan(output []byte) []Finding {
+	lines := splitLines(output)
+	seen := make(map[Finding]bool)
+	var findings []Finding
+	add := func(name string, line int) {
+		f := Finding{Rule: name, Line: line + 1}
+		if seen[f] || s.allowed(lines[line]) {
+			return
+		}
+		seen[f] = true
+		findings = append(findings, f)
+	}
+	for _, r := range s.rules {
+		for _, match := range r.pattern.FindAllIndex(output, -1) {
+			add(r.name, lineOf(lines, match[0]))
+		}
+	}
+	if s.entropy.Threshold > 0 {
+		for i, line := range lines {
+			for _, token := range tokenPattern.FindAll(line.text, -1) {
+				if s.random(token) {
+					add(EntropyRule, i)
+					break
+				}
+			}
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Line < findings[j].Line
+	})
+	return findings
+}
+
+// random reports whether token looks like a random string rather than a word or number.
+func (s *Scanner) random(token []byte) bool {
+	if len(token) < s.entropy.MinLength || hexPattern.Match(token) {
+		return false
+	}
+	return shannon(token) >= s.entropy.Threshold
+}
+
+func (s *Scanner) allowed(l line) bool {
+	if publicKeyPattern.Match(l.text) {
+		return true
+	}
+	for _, pattern := range s.allow {
+		if pattern.Match(l.text) {
+			return true
+		}
+	}
+	return false
+}
+
+type line struct {
+	start int
+	text  []byte
+}
+
+func splitLines(output []byte) []line {
+	lines := []line{{start: 0}}
+	for i, b := range output {
+		if b == '\n' {
+			last := &lines[len(lines)-1]
+			last.text = output[last.start:i]
+			lines = append(lines, line{start: i + 1})
+		}
+	}
+	last := &lines[len(lines)-1]
+	last.text = output[last.start:]
+	return lines
+}
+
+// lineOf returns the index of the line holding offset.
+func lineOf(lines []line, offset int) int {
+	return sort.Search(len(lines), func(i int) bool { return lines[i].start > offset }) - 1
+}
+
+// shannon returns the Shannon entropy of s in bits per byte.
+func shannon(s []byte) float64 {
+	var counts [256]int
+	for _, b := range s {
+		counts[b]++
+	}
+	entropy := 0.0
+	n := float64(len(s))
+	for _, c := range counts {
+		if c > 0 {
+			p := float64(c) / n
+			entropy -= p * math.Log2(p)
+		}
+	}
+	return entropy
+}
@@ -0,0 +1,380 @@
+// Package spool persists incoming backups on disk until they have been committed,
+// so an acknowledged backup survives a crash or a failing git commit.
+package spool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"vhs/devices"
+)
+
+const (
+	entrySuffix   = ".json"
+	deadDir       = "dead"
+	quarantineDir = "quarantine"
+	// drainPollInterval is how often Drain checks whether the spool is empty.
+	drainPollInterval = 50 * time.Millisecond
+)
+
+// ErrClosed is returned by Enqueue and Quarantine once the spool is closed.
+var ErrClosed = errors.New("spool is closed")
+
+// Entry is a spooled backup waiting to be committed.
+type Entry struct {
+	ID       string
+	Device   devices.Device
+	Received time.Time
+	Attempts int
+}
+
+// record is the on-disk representation of an Entry.
+type record struct {
+	ID         string             `json:"id"`
+	Host       string             `json:"host"`
+	Type       string             `json:"type,omitempty"`
+	Attributes map[string]string  `json:"attributes,omitempty"`
+	Metadata   devices.Metadata   `json:"metadata"`
+	Payload    []byte             `json:"payload"`
+	Artifacts  []devices.Artifact `json:"artifacts,omitempty"`
+	Received   time.Time          `json:"received"`
+	Attempts   int                `json:"attempts"`
+	LastError  string             `json:"last_error,omitempty"`
+	// Reason explains why a quarantined entry was set aside.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Spool is a write-ahead queue of backups stored as one file per entry in a directory.
+// Entries are handed out in the order they were enqueued; entries that fail maxAttempts
+// times are moved to the dead-letter directory. Backups that must not be committed at all
+// are kept in the quarantine directory for an operator to look at.
+type Spool struct {
+	dir         string
+	maxAttempts int
+
+	mu       sync.Mutex
+	pending  []string
+	inflight map[string]bool
+	lastID   string
+	ready    chan struct{}
+	closed   bool
+}
+
+// Open opens the spool in dir, creating it if needed. Entries left over from a previous
+// run are queued again in their original order.
+func Open(dir string, maxAttempts int) (*Spool, error) {
+	if maxAttempts < 1 {
+		return nil, fmt.Errorf("max attempts must be at least 1, got %d", maxAttempts)
+	}
+	for _, sub := range []string{deadDir, quarantineDir} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create spool directory: %w", err)
+		}
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory: %w", err)
+	}
+	s := &Spool{
+		dir:         dir,
+		maxAttempts: maxAttempts,
+		inflight:    make(map[string]bool),
+		ready:       make(chan struct{}, 1),
+	}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), entrySuffix) {
+			continue
+		}
+		s.pending = append(s.pending, strings.TrimSuffix(file.Name(), entrySuffix))
+	}
+	sort.Strings(s.pending)
+	if len(s.pending) > 0 {
+		s.lastID = s.pending[len(s.pending)-1]
+		s.signal()
+	}
+	return s, nil
+}
+
+// Enqueue durably stores a device and returns the ID of its entry. Once Enqueue returns
+// without error the backup will be handed out by Next, even after a restart.
+func (s *Spool) Enqueue(device devices.Device) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return "", ErrClosed
+	}
+	id := s.nextID()
+	rec := record{
+		ID:         id,
+		Host:       device.Name,
+		Type:       device.Type,
+		Attributes: device.Attributes,
+		Metadata:   device.Metadata,
+		Payload:    device.Payload,
+		Artifacts:  device.Artifacts,
+		Received:   time.Now(),
+	}
+	if err := s.write(rec); err != nil {
+		return "", err
+	}
+	s.lastID = id
+	s.pending = append(s.pending, id)
+	s.signal()
+	return id, nil
+}
+
+// Quarantine durably stores a device that must not be committed in the quarantine
+// directory, along with the reason, and returns the ID of its entry. Quarantined entries
+// are never handed out by Next.
+func (s *Spool) Quarantine(device devices.Device, reason string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return "", ErrClosed
+	}
+	id := s.nextID()
+	rec := record{
+		ID:         id,
+		Host:       device.Name,
+		Type:       device.Type,
+		Attributes: device.Attributes,
+		Metadata:   device.Metadata,
+		Payload:    device.Payload,
+		Artifacts:  device.Artifacts,
+		Received:   time.Now(),
+		Reason:     reason,
+	}
+	if err := s.writeFile(filepath.Join(s.dir, quarantineDir), rec); err != nil {
+		return "", err
+	}
+	s.lastID = id
+	return id, nil
+}
+
+// Next blocks until an entry is available or ctx is done. The entry stays on disk until
+// it is passed to Ack or Fail.
+func (s *Spool) Next(ctx context.Context) (Entry, error) {
+	for {
+		s.mu.Lock()
+		for len(s.pending) > 0 {
+			id := s.pending[0]
+			s.pending = s.pending[1:]
+			rec, err := s.read(id)
+			if err != nil {
+				// An unreadable entry will never succeed, park it with the dead letters.
+				if err := s.moveToDead(id); err != nil {
+					log.Printf("Failed to park unreadable spool entry %s: %v\n", id, err)
+				}
+				continue
+			}
+			s.inflight[id] = true
+			s.mu.Unlock()
+			device := devices.NewDevice(rec.Host, rec.Payload)
+			device.Type = rec.Type
+			device.Attributes = rec.Attributes
+			device.Metadata = rec.Metadata
+			device.Artifacts = rec.Artifacts
+			return Entry{
+				ID:       rec.ID,
+				Device:   device,
+				Received: rec.Received,
+				Attempts: rec.Attempts,
+			}, nil
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return Entry{}, ctx.Err()
+		case <-s.ready:
+		}
+	}
+}
+
+// Ack removes an entry that has been committed.
+func (s *Spool) Ack(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.inflight, id)
+	if err := os.Remove(s.path(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove spool entry %s: %w", id, err)
+	}
+	return nil
+}
+
+// Fail records a failed attempt at committing an entry. The entry is queued again ahead
+// of newer entries, or moved to the dead-letter directory once it ran out of attempts.
+// It reports whether the entry was dead-lettered.
+func (s *Spool) Fail(id string, cause error) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.inflight, id)
+	rec, err := s.read(id)
+	if err != nil {
+		return true, s.moveToDead(id)
+	}
+	rec.Attempts++
+	rec.LastError = cause.Error()
+	if err := s.write(rec); err != nil {
+		// The entry is still on disk as it was, keep retrying it rather than leaving it
+		// behind until the next restart.
+		s.requeue(id)
+		return false, err
+	}
+	if rec.Attempts >= s.maxAttempts {
+		if err := s.moveToDead(id); err != nil {
+			s.requeue(id)
+			return false, err
+		}
+		return true, nil
+	}
+	s.requeue(id)
+	return false, nil
+}
+
+// requeue queues an entry again ahead of newer entries. The caller holds s.mu.
+func (s *Spool) requeue(id string) {
+	// Entry IDs sort in arrival order, so this puts the entry back where it was.
+	i := sort.SearchStrings(s.pending, id)
+	s.pending = append(s.pending, "")
+	copy(s.pending[i+1:], s.pending[i:])
+	s.pending[i] = id
+	s.signal()
+}
+
+// Len returns the number of entries waiting to be committed, including the ones being processed.
+func (s *Spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending) + len(s.inflight)
+}
+
+// Close stops the spool from taking new entries. The entries already spooled are still
+// handed out by Next, so they can be drained.
+func (s *Spool) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+}
+
+// Drain blocks until every entry has been committed or dead-lettered, or ctx is done.
+// Entries left when ctx is done stay on disk and are replayed when the spool is opened again.
+func (s *Spool) Drain(ctx context.Context) error {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for s.Len() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// nextID returns a new entry ID that sorts after every existing one.
+func (s *Spool) nextID() string {
+	id := fmt.Sprintf("%019d", time.Now().UnixNano())
+	if id <= s.lastID {
+		id = incrementID(s.lastID)
+	}
+	return id
+}
+
+// incrementID adds one to a zero padded decimal ID.
+func incrementID(id string) string {
+	digits := []byte(id)
+	for i := len(digits) - 1; i >= 0; i-- {
+		if digits[i] < '9' {
+			digits[i]++
+			return string(digits)
+		}
+		digits[i] = '0'
+	}
+	return "1" + string(digits)
+}
+
+func (s *Spool) signal() {
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Spool) path(id string) string {
+	return filepath.Join(s.dir, id+entrySuffix)
+}
+
+func (s *Spool) read(id string) (record, error) {
+	var rec record
+	content, err := ioutil.ReadFile(s.path(id))
+	if err != nil {
+		return rec, err
+	}
+	if err := json.Unmarshal(content, &rec); err != nil {
+		return rec, fmt.Errorf("failed to decode spool entry %s: %w", id, err)
+	}
+	return rec, nil
+}
+
+// write atomically replaces an entry's file and flushes it to disk.
+func (s *Spool) write(rec record) error {
+	return s.writeFile(s.dir, rec)
+}
+
+// writeFile atomically replaces the file of an entry in dir and flushes it to disk.
+func (s *Spool) writeFile(dir string, rec record) error {
+	content, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, ".tmp-")
+	if err != nil {
+		return fmt.Errorf("failed to create spool entry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write spool entry: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync spool entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close spool entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, rec.ID+entrySuffix)); err != nil {
+		return fmt.Errorf("failed to store spool entry: %w", err)
+	}
+	return syncDir(dir)
+}
+
+func (s *Spool) moveToDead(id string) error {
+	if err := os.Rename(s.path(id), filepath.Join(s.dir, deadDir, id+entrySuffix)); err != nil {
+		return fmt.Errorf("failed to dead-letter spool entry %s: %w", id, err)
+	}
+	return nil
+}
+
+// syncDir flushes a directory so that a rename within it survives a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("failed to sync spool directory: %w", err)
+	}
+	return nil
+}
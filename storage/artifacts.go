@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"vhs/devices"
+)
+
+// ArtifactsDir holds the artifacts of the configurations in a store, as one folder per
+// configuration at the same path holding a file per artifact.
+const ArtifactsDir = ".artifacts"
+
+// ArtifactsPath returns the path of the folder holding the artifacts of the configuration
+// at path, relative to the root of a store.
+func ArtifactsPath(path string) string {
+	return filepath.Join(ArtifactsDir, path)
+}
+
+// ArtifactName returns the name an artifact of a device is read by with Get, History and
+// Diff. Device names cannot contain slashes, so it never names a device.
+func ArtifactName(device string, artifact string) string {
+	return device + "/" + artifact
+}
+
+// SplitName splits a name read from a store into the device name and the artifact name,
+// which is empty when the name refers to the device's configuration.
+func SplitName(name string) (string, string) {
+	device, artifact, _ := strings.Cut(name, "/")
+	return device, artifact
+}
+
+// Companions returns the paths stored alongside the configuration at path, relative to
+// the root of a store. They move with the configuration when it is relocated or deprecated.
+func Companions(path string) []string {
+	return []string{MetadataPath(path), ArtifactsPath(path)}
+}
+
+// WriteArtifacts writes artifacts to the folder at dir below root, each to a file named
+// after it behind the timestamp header. Artifacts whose payload did not change are not
+// rewritten, so their history only holds changes. It returns the paths of the files it
+// wrote, relative to root.
+func WriteArtifacts(root string, dir string, artifacts []devices.Artifact, timestamp string) ([]string, error) {
+	var written []string
+	for _, artifact := range artifacts {
+		path := filepath.Join(dir, artifact.Name)
+		ok, err := WriteConfiguration(root, path, artifact.Payload, timestamp)
+		if err != nil {
+			return written, err
+		}
+		if ok {
+			written = append(written, path)
+		}
+	}
+	return written, nil
+}
+
+// WriteConfiguration writes payload to the file at path below root behind the timestamp
+// header, unless the file already holds the same payload. The header keeps the time of the
+// backup that last changed the payload, so backups without changes leave the file alone.
+// It reports whether the file was written.
+func WriteConfiguration(root string, path string, payload []byte, timestamp string) (bool, error) {
+	file := filepath.Join(root, path)
+	content, err := ioutil.ReadFile(file)
+	if err == nil {
+		if _, stored := SplitHeader(content); bytes.Equal(stored, payload) {
+			return false, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		return false, err
+	}
+	if err := ioutil.WriteFile(file, []byte(fmt.Sprintf("%s\n%s", timestamp, payload)), 0644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ListArtifacts returns the names of the artifacts in the folder at dir below root, sorted.
+func ListArtifacts(root string, dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(filepath.Join(root, dir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, file := range files {
+		if devices.ValidateName(file.Name()) == nil {
+			names = append(names, file.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"vhs/devices"
+)
+
+// Cipher encrypts payloads before they are stored and decrypts them when they are read.
+type Cipher interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	// Decrypt returns content that was not encrypted unchanged.
+	Decrypt(content []byte) ([]byte, error)
+}
+
+// EncryptedStore encrypts the configurations and artifacts saved to a Store and decrypts
+// them when they are read. Paths, metadata, timestamps and commit messages stay in the
+// clear, so listing, history and deprecation work as before.
+type EncryptedStore struct {
+	Store
+	cipher Cipher
+}
+
+var (
+	_ Store          = (*EncryptedStore)(nil)
+	_ RevisionWalker = (*EncryptedStore)(nil)
+)
+
+// NewEncryptedStore wraps store so that everything saved to it is encrypted with cipher.
+func NewEncryptedStore(store Store, cipher Cipher) *EncryptedStore {
+	return &EncryptedStore{Store: store, cipher: cipher}
+}
+
+// Save encrypts the device and saves it to the wrapped store.
+func (e *EncryptedStore) Save(device devices.Device) (string, error) {
+	device, err := e.EncryptDevice(device)
+	if err != nil {
+		return "", err
+	}
+	return e.Store.Save(device)
+}
+
+// EncryptDevice returns a copy of device with its configuration and artifacts encrypted,
+// for saving it to the wrapped store by other means than Save. Encryption is randomized,
+// so payloads equal to the stored ones keep their stored ciphertext; otherwise every
+// backup would look like a change.
+func (e *EncryptedStore) EncryptDevice(device devices.Device) (devices.Device, error) {
+	if err := device.Validate(); err != nil {
+		return devices.Device{}, err
+	}
+	payload, err := e.encrypt(device.Name, device.Payload)
+	if err != nil {
+		return devices.Device{}, err
+	}
+	device.Payload = payload
+	artifacts := make([]devices.Artifact, len(device.Artifacts))
+	for i, artifact := range device.Artifacts {
+		payload, err := e.encrypt(ArtifactName(device.Name, artifact.Name), artifact.Payload)
+		if err != nil {
+			return devices.Device{}, err
+		}
+		artifacts[i] = devices.Artifact{Name: artifact.Name, Payload: payload}
+	}
+	if device.Artifacts != nil {
+		device.Artifacts = artifacts
+	}
+	return device, nil
+}
+
+// encrypt encrypts the payload about to be stored under name, or returns the stored
+// ciphertext when it holds the same payload.
+func (e *EncryptedStore) encrypt(name string, payload []byte) ([]byte, error) {
+	latest, err := e.Store.Get(name, Query{})
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	if err == nil {
+		// Without the means to decrypt the stored payload it counts as changed.
+		if previous, err := e.cipher.Decrypt(latest.Payload); err == nil && bytes.Equal(previous, payload) && !bytes.Equal(previous, latest.Payload) {
+			return latest.Payload, nil
+		}
+	}
+	return e.cipher.Encrypt(payload)
+}
+
+// Get returns the decrypted revision selected by query.
+func (e *EncryptedStore) Get(name string, query Query) (Revision, error) {
+	revision, err := e.Store.Get(name, query)
+	if err != nil {
+		return Revision{}, err
+	}
+	if revision.Payload, err = e.cipher.Decrypt(revision.Payload); err != nil {
+		return Revision{}, err
+	}
+	return revision, nil
+}
+
+// History returns the revisions of a device with the size of their decrypted payloads.
+// Entries the wrapped store reports without a payload, such as the commit that deprecated
+// the device, are kept as they are.
+func (e *EncryptedStore) History(name string, opts HistoryOptions) ([]HistoryEntry, bool, error) {
+	entries, more, err := e.Store.History(name, opts)
+	if err != nil {
+		return nil, false, err
+	}
+	for i, entry := range entries {
+		if entry.PayloadSize == 0 {
+			continue
+		}
+		revision, err := e.Store.Get(name, Query{Revision: entry.Commit})
+		if errors.Is(err, ErrNotFound) {
+			entries[i].PayloadSize = 0
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		payload, err := e.cipher.Decrypt(revision.Payload)
+		if err != nil {
+			return nil, false, err
+		}
+		entries[i].PayloadSize = int64(len(payload))
+	}
+	return entries, more, nil
+}
+
+// WalkRevisions walks the decrypted revisions of the wrapped store, which must be a
+// RevisionWalker.
+func (e *EncryptedStore) WalkRevisions(fn func(revision Revision) error) error {
+	walker, ok := e.Store.(RevisionWalker)
+	if !ok {
+		return errors.New("the store cannot walk its revisions")
+	}
+	return walker.WalkRevisions(func(revision Revision) error {
+		payload, err := e.cipher.Decrypt(revision.Payload)
+		if err != nil {
+			return err
+		}
+		revision.Payload = payload
+		return fn(revision)
+	})
+}
+
+// Diff diffs the decrypted revisions, as the wrapped store only sees ciphertext.
+func (e *EncryptedStore) Diff(name string, from string, to string) (string, error) {
+	return DiffRevisions(e, name, from, to)
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"vhs/devices"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCipher encodes payloads with a counter, so that encryption is randomized like the
+// real one.
+type testCipher struct {
+	seq int64
+}
+
+var testCipherPrefix = []byte("encrypted:")
+
+func (c *testCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	return []byte(fmt.Sprintf("%s%d:%s", testCipherPrefix, atomic.AddInt64(&c.seq, 1), base64.StdEncoding.EncodeToString(plaintext))), nil
+}
+
+func (c *testCipher) Decrypt(content []byte) ([]byte, error) {
+	if !bytes.HasPrefix(content, testCipherPrefix) {
+		return content, nil
+	}
+	_, encoded, _ := bytes.Cut(bytes.TrimPrefix(content, testCipherPrefix), []byte(":"))
+	return base64.StdEncoding.DecodeString(string(encoded))
+}
+
+func TestEncryptedStore(t *testing.T) {
+	testStore(t, NewEncryptedStore(NewMemoryStore(), &testCipher{}))
+
+	inner := NewMemoryStore()
+	store := NewEncryptedStore(inner, &testCipher{})
+	// Revisions stored before encryption was turned on are read as they are.
+	plain, err := inner.Save(devices.NewDevice("core-01", []byte("hostname core-01\n")))
+	require.NoError(t, err)
+	device := devices.NewDevice("core-01", []byte("hostname core-01\n"))
+	device.Artifacts = []devices.Artifact{{Name: "show_version", Payload: []byte("EOS 4.28\n")}}
+	encrypted, err := store.Save(device)
+	require.NoError(t, err)
+	assert.NotEqual(t, plain, encrypted, "a plain revision is encrypted even when it did not change")
+	assert.Equal(t, "hostname core-01\n", string(device.Payload), "the device passed in is not modified")
+
+	stored, err := inner.Get("core-01", Query{})
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(stored.Payload, testCipherPrefix))
+	stored, err = inner.Get(ArtifactName("core-01", "show_version"), Query{})
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(stored.Payload, testCipherPrefix))
+
+	unchanged, err := store.Save(device)
+	require.NoError(t, err)
+	assert.Equal(t, encrypted, unchanged, "saving the same configuration does not create a revision")
+	old, err := store.Get("core-01", Query{Revision: plain})
+	require.NoError(t, err)
+	assert.Equal(t, "hostname core-01\n", string(old.Payload))
+	artifact, err := store.Get(ArtifactName("core-01", "show_version"), Query{})
+	require.NoError(t, err)
+	assert.Equal(t, "EOS 4.28\n", string(artifact.Payload))
+
+	device.Payload = []byte("hostname core-01\nvlan 10\n")
+	changed, err := store.Save(device)
+	require.NoError(t, err)
+	diff, err := store.Diff("core-01", encrypted, changed)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+vlan 10\n")
+	entries, _, err := store.History(ArtifactName("core-01", "show_version"), HistoryOptions{})
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "an unchanged artifact keeps its revision")
+}
+
+// removingStore reports an extra history entry for a commit that removed the device, like
+// the git stores do once it was deprecated.
+type removingStore struct {
+	*MemoryStore
+}
+
+func (r removingStore) History(name string, opts HistoryOptions) ([]HistoryEntry, bool, error) {
+	entries, more, err := r.MemoryStore.History(name, opts)
+	return append([]HistoryEntry{{Commit: "removed", Message: "Deprecated " + name}}, entries...), more, err
+}
+
+func TestEncryptedStoreHistoryWithRemoval(t *testing.T) {
+	store := NewEncryptedStore(removingStore{NewMemoryStore()}, &testCipher{})
+	commit, err := store.Save(devices.NewDevice("core-01", []byte("hostname core-01\n")))
+	require.NoError(t, err)
+
+	entries, _, err := store.History("core-01", HistoryOptions{})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "removed", entries[0].Commit)
+	assert.Zero(t, entries[0].PayloadSize)
+	assert.Equal(t, commit, entries[1].Commit)
+	assert.Equal(t, int64(len("hostname core-01\n")), entries[1].PayloadSize)
+}
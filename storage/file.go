@@ -0,0 +1,385 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"vhs/devices"
+)
+
+const (
+	// fileMetadata is the name of the metadata file in a FileStore device directory.
+	fileMetadata = "metadata.yaml"
+	// fileArtifacts is the folder in a FileStore device directory holding a revision
+	// directory per artifact.
+	fileArtifacts = "artifacts"
+)
+
+// FileStore keeps every revision of a device as its own file in a plain directory tree,
+// <dir>/<layout path>/<revision>, for deployments that do not want a git remote.
+// Revision IDs are the zero padded UnixNano time the revision was saved. The metadata of
+// the latest backup is kept next to the revisions in metadata.yaml, and the revisions of
+// each artifact in artifacts/<artifact>/<revision>. The last backup of every device is
+// recorded in the SeenFile at the root, as unchanged backups add no revision.
+type FileStore struct {
+	dir    string
+	layout *Layout
+	index  *Index
+	seen   *Seen
+
+	mu     sync.Mutex
+	lastID string
+}
+
+var (
+	_ Store          = (*FileStore)(nil)
+	_ Migrator       = (*FileStore)(nil)
+	_ RevisionWalker = (*FileStore)(nil)
+)
+
+// NewFileStore creates a FileStore in dir, creating the directory if needed. A nil layout
+// uses the DefaultTemplate.
+func NewFileStore(dir string, layout *Layout) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %w", err)
+	}
+	return &FileStore{dir: dir, layout: layout, index: NewIndex(dir), seen: NewSeen(filepath.Join(dir, SeenFile))}, nil
+}
+
+func (f *FileStore) Save(device devices.Device) (string, error) {
+	if err := device.Validate(); err != nil {
+		return "", err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := f.layout.Path(device)
+	deviceDir := filepath.Join(f.dir, path)
+	if old, ok := f.index.Find(f.layout, device.Name); ok && old != path {
+		// The device was classified or described differently before, keep its revisions together.
+		if err := os.MkdirAll(filepath.Dir(deviceDir), os.ModePerm); err != nil {
+			return "", err
+		}
+		if err := os.Rename(filepath.Join(f.dir, old), deviceDir); err != nil {
+			return "", err
+		}
+	}
+	if _, err := WriteMetadata(deviceDir, fileMetadata, device.Metadata); err != nil {
+		return "", err
+	}
+	for _, artifact := range device.Artifacts {
+		dir := filepath.Join(deviceDir, fileArtifacts, artifact.Name)
+		if _, err := f.saveRevision(dir, ArtifactName(device.Name, artifact.Name), artifact.Payload); err != nil {
+			return "", err
+		}
+	}
+	revision, err := f.saveRevision(deviceDir, device.Name, device.Payload)
+	if err != nil {
+		return "", err
+	}
+	f.index.Set(device.Name, path)
+	if err := f.seen.Record(time.Now(), device.Name); err != nil {
+		return "", err
+	}
+	return revision, nil
+}
+
+// saveRevision adds payload as a revision to the revision directory dir, unless it equals
+// the latest revision there.
+func (f *FileStore) saveRevision(dir string, name string, payload []byte) (string, error) {
+	revisions, err := f.revisions(dir, name)
+	if err != nil {
+		return "", err
+	}
+	if n := len(revisions); n > 0 {
+		latest, err := f.read(dir, revisions[n-1])
+		if err != nil {
+			return "", err
+		}
+		if bytes.Equal(latest.Payload, payload) {
+			return latest.Commit, nil
+		}
+	}
+
+	id := fmt.Sprintf("%019d", time.Now().UnixNano())
+	if id <= f.lastID {
+		next, _ := strconv.ParseInt(f.lastID, 10, 64)
+		id = fmt.Sprintf("%019d", next+1)
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+	content := fmt.Sprintf("%s\n%s", time.Now().Format(time.RFC3339), string(payload))
+	tmp := filepath.Join(dir, "."+id)
+	if err := ioutil.WriteFile(tmp, []byte(content), 0644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, id)); err != nil {
+		return "", err
+	}
+	f.lastID = id
+	return id, nil
+}
+
+func (f *FileStore) List() ([]DeviceInfo, error) {
+	infos, err := f.list(f.layout)
+	if err != nil {
+		return nil, err
+	}
+	f.seen.Update(infos)
+	return infos, nil
+}
+
+// list lists the device directories laid out by layout that hold at least one revision.
+func (f *FileStore) list(layout *Layout) ([]DeviceInfo, error) {
+	var infos []DeviceInfo
+	err := layout.walk(f.dir, func(path string, name string, fields map[string]string, info os.FileInfo) error {
+		if !info.IsDir() {
+			return nil
+		}
+		revisions, err := f.revisions(filepath.Join(f.dir, path), name)
+		if err != nil || len(revisions) == 0 {
+			return err
+		}
+		metadata, err := ReadMetadata(filepath.Join(f.dir, path), fileMetadata)
+		if err != nil {
+			return err
+		}
+		artifacts, err := ListArtifacts(filepath.Join(f.dir, path), fileArtifacts)
+		if err != nil {
+			return err
+		}
+		infos = append(infos, DeviceInfo{
+			Name:       name,
+			DeviceType: fields[typeField],
+			Path:       path,
+			LastBackup: revisions[len(revisions)-1].Timestamp,
+			Metadata:   metadata,
+			Artifacts:  artifacts,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+func (f *FileStore) Get(name string, query Query) (Revision, error) {
+	deviceDir := f.deviceDir(name)
+	revisions, err := f.revisions(deviceDir, name)
+	if err != nil {
+		return Revision{}, err
+	}
+	index, err := selectRevision(revisions, query)
+	if err != nil {
+		return Revision{}, err
+	}
+	return f.read(deviceDir, revisions[index])
+}
+
+func (f *FileStore) History(name string, opts HistoryOptions) ([]HistoryEntry, bool, error) {
+	deviceDir := f.deviceDir(name)
+	revisions, err := f.revisions(deviceDir, name)
+	if err != nil {
+		return nil, false, err
+	}
+	indexes, more := selectHistory(revisions, opts)
+	var entries []HistoryEntry
+	for _, i := range indexes {
+		revision, err := f.read(deviceDir, revisions[i])
+		if err != nil {
+			return nil, false, err
+		}
+		entries = append(entries, HistoryEntry{
+			Commit:      revision.Commit,
+			Timestamp:   revisions[i].Timestamp,
+			Message:     saveMessage(name),
+			PayloadSize: int64(len(revision.Payload)),
+		})
+	}
+	return entries, more, nil
+}
+
+func (f *FileStore) Diff(name string, from string, to string) (string, error) {
+	return DiffRevisions(f, name, from, to)
+}
+
+// Deprecate moves the revisions of devices not backed up within maxAge below deprecated/,
+// next to those of earlier deprecations.
+func (f *FileStore) Deprecate(maxAge time.Duration) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	infos, err := f.List()
+	if err != nil {
+		return 0, err
+	}
+	deprecated := 0
+	for _, info := range infos {
+		if time.Since(info.LastBackup) <= maxAge {
+			continue
+		}
+		if err := mergeDir(filepath.Join(f.dir, info.Path), filepath.Join(f.dir, DeprecatedDir, info.Path)); err != nil {
+			return deprecated, err
+		}
+		deprecated++
+	}
+	return deprecated, nil
+}
+
+// mergeDir moves the contents of the directory source into target and removes source.
+// Directories that exist on both sides, such as the revision directory of an artifact
+// deprecated before, are merged; revision IDs are unique, and metadata.yaml is replaced.
+func mergeDir(source string, target string) error {
+	if err := os.MkdirAll(target, os.ModePerm); err != nil {
+		return err
+	}
+	files, err := ioutil.ReadDir(source)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		from, to := filepath.Join(source, file.Name()), filepath.Join(target, file.Name())
+		if existing, err := os.Stat(to); err == nil && existing.IsDir() && file.IsDir() {
+			if err := mergeDir(from, to); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.Rename(from, to); err != nil {
+			return err
+		}
+	}
+	return os.Remove(source)
+}
+
+// WalkRevisions implements RevisionWalker, including the revisions of deprecated devices.
+func (f *FileStore) WalkRevisions(fn func(revision Revision) error) error {
+	for _, root := range []string{f.dir, filepath.Join(f.dir, DeprecatedDir)} {
+		err := f.layout.walk(root, func(path string, name string, fields map[string]string, info os.FileInfo) error {
+			if !info.IsDir() {
+				return nil
+			}
+			dirs := map[string]string{name: filepath.Join(root, path)}
+			artifacts, err := ListArtifacts(filepath.Join(root, path), fileArtifacts)
+			if err != nil {
+				return err
+			}
+			for _, artifact := range artifacts {
+				dirs[ArtifactName(name, artifact)] = filepath.Join(root, path, fileArtifacts, artifact)
+			}
+			for name, dir := range dirs {
+				revisions, err := f.revisions(dir, name)
+				if err != nil {
+					return err
+				}
+				for i := len(revisions) - 1; i >= 0; i-- {
+					revision, err := f.read(dir, revisions[i])
+					if err != nil {
+						return err
+					}
+					if err := fn(revision); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// PlanMigration returns the moves Migrate would make.
+func (f *FileStore) PlanMigration(from *Layout) ([]Move, error) {
+	infos, err := f.list(from)
+	if err != nil {
+		return nil, err
+	}
+	return PlanMoves(f.dir, from, f.layout, infos)
+}
+
+// Migrate moves the revisions of every device laid out by from to where the store's
+// layout puts them.
+func (f *FileStore) Migrate(from *Layout) ([]Move, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	defer f.index.Reset()
+	moves, err := f.PlanMigration(from)
+	if err != nil {
+		return nil, err
+	}
+	for i, move := range moves {
+		target := filepath.Join(f.dir, move.To)
+		if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+			return moves[:i], err
+		}
+		if err := os.Rename(filepath.Join(f.dir, move.From), target); err != nil {
+			return moves[:i], err
+		}
+	}
+	return moves, nil
+}
+
+// Sync does nothing, a FileStore has no remote.
+func (f *FileStore) Sync() error {
+	return nil
+}
+
+// SyncStatus always reports a zero status, a FileStore has no remote.
+func (f *FileStore) SyncStatus() (SyncStatus, error) {
+	return SyncStatus{}, nil
+}
+
+// deviceDir returns the revision directory of a device, or of an artifact when name is an
+// ArtifactName.
+func (f *FileStore) deviceDir(name string) string {
+	device, artifact := SplitName(name)
+	dir := filepath.Join(f.dir, f.index.Locate(f.layout, device))
+	if artifact != "" {
+		return filepath.Join(dir, fileArtifacts, artifact)
+	}
+	return dir
+}
+
+// revisions lists the revisions in a device directory oldest first, without payloads.
+// Their Timestamp is the time they were saved, taken from the revision ID.
+func (f *FileStore) revisions(deviceDir string, name string) ([]Revision, error) {
+	files, err := ioutil.ReadDir(deviceDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var revisions []Revision
+	for _, file := range files {
+		nanos, err := strconv.ParseInt(file.Name(), 10, 64)
+		if err != nil || file.IsDir() {
+			continue
+		}
+		revisions = append(revisions, Revision{
+			Device:    name,
+			Commit:    file.Name(),
+			Timestamp: time.Unix(0, nanos),
+		})
+	}
+	return revisions, nil
+}
+
+func (f *FileStore) read(deviceDir string, revision Revision) (Revision, error) {
+	content, err := ioutil.ReadFile(filepath.Join(deviceDir, revision.Commit))
+	if err != nil {
+		return Revision{}, fmt.Errorf("failed to read revision %s of %s: %w", revision.Commit, revision.Device, err)
+	}
+	revision.Timestamp, revision.Payload = SplitHeader(content)
+	return revision, nil
+}
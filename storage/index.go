@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"vhs/devices"
+)
+
+// Index remembers where the configuration of each device below a root is. The path may
+// depend on fields sent with the backup and cannot always be derived from the name, and
+// walking the tree for every device of a large run does not scale. The index is built by
+// walking the tree once; the store owning the tree records the paths it saves to with Set,
+// and calls Reset when the tree changed otherwise, e.g. by pulling or migrating.
+type Index struct {
+	root string
+
+	mu sync.Mutex
+	// layout is the layout paths was built with; paths is nil until it is built.
+	layout *Layout
+	paths  map[string]string
+}
+
+// NewIndex creates an Index of the configurations below root.
+func NewIndex(root string) *Index {
+	return &Index{root: root}
+}
+
+// Find returns the path of a device's configuration laid out by layout, relative to the
+// root, and whether the tree holds one.
+func (x *Index) Find(layout *Layout, name string) (string, bool) {
+	if devices.ValidateName(name) != nil {
+		return "", false
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.paths == nil || x.layout != layout {
+		if err := x.build(layout); err != nil {
+			return "", false
+		}
+	}
+	path, ok := x.paths[name]
+	if !ok {
+		return "", false
+	}
+	if _, err := os.Stat(filepath.Join(x.root, path)); err != nil {
+		// The configuration was deprecated since.
+		delete(x.paths, name)
+		return "", false
+	}
+	return path, true
+}
+
+// Locate returns the path of a device's configuration laid out by layout, relative to the
+// root. Devices not in the tree are assumed to be where a device without attributes would
+// be put.
+func (x *Index) Locate(layout *Layout, name string) string {
+	if path, ok := x.Find(layout, name); ok {
+		return path
+	}
+	return layout.Path(devices.NewDevice(name, nil))
+}
+
+// Set records that a device's configuration was saved to path, relative to the root.
+func (x *Index) Set(name string, path string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.paths != nil {
+		x.paths[name] = path
+	}
+}
+
+// Reset forgets every path, the tree is walked again on the next Find.
+func (x *Index) Reset() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.paths = nil
+}
+
+func (x *Index) build(layout *Layout) error {
+	paths := make(map[string]string)
+	err := layout.walk(x.root, func(path string, name string, fields map[string]string, info os.FileInfo) error {
+		if _, ok := paths[name]; !ok {
+			paths[name] = path
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	x.layout, x.paths = layout, paths
+	return nil
+}
@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"vhs/devices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultTemplate lays configurations out as <DeviceType>/<Name>.
+const DefaultTemplate = "{type}/{hostname}"
+
+// MissingField replaces template fields a device has no valid value for.
+const MissingField = "unknown"
+
+const (
+	hostnameField = "hostname"
+	typeField     = "type"
+)
+
+var (
+	fieldPattern = regexp.MustCompile(`\{([a-z0-9_]+)\}`)
+	// defaultLayout is used by a nil *Layout.
+	defaultLayout, _ = NewLayout(DefaultTemplate, "")
+)
+
+// Layout decides where in a store a device's configuration lives. Its template is a slash
+// separated path in which {hostname} stands for the device name, {type} for the device
+// type and any other {field} for the attribute of that name sent with the backup, the
+// metadata field of that name, or else the inventory field of that name. A nil *Layout uses the DefaultTemplate.
+type Layout struct {
+	template string
+	// pattern matches paths laid out by the template, capturing the fields named in groups.
+	pattern   *regexp.Regexp
+	groups    []string
+	inventory map[string]map[string]string
+}
+
+// NewLayout parses a template. inventoryFile optionally names a YAML file mapping device
+// names to the fields used to fill in the template.
+func NewLayout(template string, inventoryFile string) (*Layout, error) {
+	if err := validateTemplate(template); err != nil {
+		return nil, err
+	}
+	l := &Layout{template: template}
+	pattern := "^"
+	last := 0
+	for _, match := range fieldPattern.FindAllStringSubmatchIndex(template, -1) {
+		pattern += regexp.QuoteMeta(template[last:match[0]]) + "([^/]+)"
+		l.groups = append(l.groups, template[match[2]:match[3]])
+		last = match[1]
+	}
+	l.pattern = regexp.MustCompile(pattern + regexp.QuoteMeta(template[last:]) + "$")
+
+	if inventoryFile != "" {
+		content, err := ioutil.ReadFile(inventoryFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read inventory: %w", err)
+		}
+		if err := yaml.Unmarshal(content, &l.inventory); err != nil {
+			return nil, fmt.Errorf("failed to parse inventory %s: %w", inventoryFile, err)
+		}
+	}
+	return l, nil
+}
+
+func validateTemplate(template string) error {
+	if strings.Count(template, "{"+hostnameField+"}") != 1 {
+		return fmt.Errorf("template %q must contain {%s} exactly once", template, hostnameField)
+	}
+	elements := strings.Split(template, "/")
+	if !strings.Contains(elements[len(elements)-1], "{"+hostnameField+"}") {
+		return fmt.Errorf("template %q must contain {%s} in its last element", template, hostnameField)
+	}
+	for _, element := range elements {
+		if element == "" || element == "." || element == ".." {
+			return fmt.Errorf("template %q has an invalid path element %q", template, element)
+		}
+		if rest := fieldPattern.ReplaceAllString(element, ""); strings.ContainsAny(rest, `{}\`) {
+			return errors.New("template fields must look like {name}, with lower case letters, digits and underscores")
+		}
+	}
+	if first := elements[0]; first == DeprecatedDir || strings.HasPrefix(first, ".") {
+		return fmt.Errorf("template %q must not start with %s", template, first)
+	}
+	return nil
+}
+
+func (l *Layout) get() *Layout {
+	if l == nil {
+		return defaultLayout
+	}
+	return l
+}
+
+// Template returns the template the layout was created from.
+func (l *Layout) Template() string {
+	return l.get().template
+}
+
+// render fills in the template for a device, as a slash separated path.
+func (l *Layout) render(device devices.Device) string {
+	return fieldPattern.ReplaceAllStringFunc(l.template, func(field string) string {
+		name := field[1 : len(field)-1]
+		switch name {
+		case hostnameField:
+			return device.Name
+		case typeField:
+			return device.GetDeviceType()
+		}
+		value, ok := device.Attributes[name]
+		if !ok || value == "" {
+			value, ok = device.Metadata.Field(name)
+		}
+		if !ok || value == "" {
+			value = l.inventory[device.Name][name]
+		}
+		// Field values become path elements, they must not add or escape folders.
+		if !devices.ValidType(value) {
+			return MissingField
+		}
+		return value
+	})
+}
+
+// Parse extracts the device name and the template fields from a path laid out by l.
+func (l *Layout) Parse(path string) (string, map[string]string, bool) {
+	l = l.get()
+	match := l.pattern.FindStringSubmatch(filepath.ToSlash(path))
+	if match == nil {
+		return "", nil, false
+	}
+	fields := make(map[string]string, len(l.groups))
+	for i, group := range l.groups {
+		if previous, ok := fields[group]; ok && previous != match[i+1] {
+			// A field used twice must have the same value everywhere.
+			return "", nil, false
+		}
+		fields[group] = match[i+1]
+	}
+	return fields[hostnameField], fields, true
+}
+
+// Inventory returns the inventory fields of a device.
+func (l *Layout) Inventory(name string) map[string]string {
+	return l.get().inventory[name]
+}
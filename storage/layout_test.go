@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"vhs/devices"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayoutPath(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "vhs-layout")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	inventory := filepath.Join(tempDir, "inventory.yaml")
+	require.NoError(t, ioutil.WriteFile(inventory, []byte("core-01:\n  site: ams1\n  role: spine\n"), 0644))
+
+	layout, err := NewLayout("{site}/{role}/{vendor}/{hostname}.cfg", inventory)
+	require.NoError(t, err)
+	testCases := []struct {
+		name       string
+		device     string
+		attributes map[string]string
+		metadata   devices.Metadata
+		expected   string
+	}{
+		{"Inventory and attributes", "core-01", map[string]string{"vendor": "arista"}, devices.Metadata{}, "ams1/spine/arista/core-01.cfg"},
+		{"Attributes win over the inventory", "core-01", map[string]string{"vendor": "arista", "site": "fra1"}, devices.Metadata{}, "fra1/spine/arista/core-01.cfg"},
+		{"Metadata", "label-01", nil, devices.Metadata{Vendor: "juniper"}, "unknown/unknown/juniper/label-01.cfg"},
+		{"Missing fields", "label-01", nil, devices.Metadata{}, "unknown/unknown/unknown/label-01.cfg"},
+		{"Values cannot add folders", "label-01", map[string]string{"site": "../..", "role": "a/b"}, devices.Metadata{}, "unknown/unknown/unknown/label-01.cfg"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			device := devices.NewDevice(tc.device, nil)
+			device.Attributes = tc.attributes
+			device.Metadata = tc.metadata
+			assert.Equal(t, filepath.FromSlash(tc.expected), layout.Path(device))
+		})
+	}
+
+	var defaultLayout *Layout
+	assert.Equal(t, filepath.Join("Core", "core-01"), defaultLayout.Path(devices.NewDevice("core-01", nil)))
+}
+
+func TestLayoutParse(t *testing.T) {
+	layout, err := NewLayout("{site}/{type}/{hostname}.cfg", "")
+	require.NoError(t, err)
+
+	name, fields, ok := layout.Parse(filepath.Join("ams1", "Core", "core-01.cfg"))
+	require.True(t, ok)
+	assert.Equal(t, "core-01", name)
+	assert.Equal(t, map[string]string{"site": "ams1", "type": "Core", "hostname": "core-01"}, fields)
+
+	for _, path := range []string{"ams1/Core/core-01", "Core/core-01.cfg", "ams1/Core/x/core-01.cfg"} {
+		_, _, ok := layout.Parse(path)
+		assert.False(t, ok, path)
+	}
+}
+
+func TestNewLayoutErrors(t *testing.T) {
+	testCases := []struct {
+		template string
+		contains string
+	}{
+		{"{type}/configs", "must contain {hostname} exactly once"},
+		{"{hostname}/{hostname}", "must contain {hostname} exactly once"},
+		{"{hostname}/config", "in its last element"},
+		{"deprecated/{hostname}", "must not start with deprecated"},
+		{".git/{hostname}", "must not start with .git"},
+		{"{site}//{hostname}", "invalid path element"},
+		{"../{hostname}", "invalid path element"},
+		{"{Site}/{hostname}", "template fields must look like {name}"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.template, func(t *testing.T) {
+			_, err := NewLayout(tc.template, "")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.contains)
+		})
+	}
+	_, err := NewLayout(DefaultTemplate, "/does/not/exist.yaml")
+	assert.Error(t, err)
+}
+
+func TestIndex(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "vhs-layout")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	layout, err := NewLayout("{site}/{hostname}.cfg", "")
+	require.NoError(t, err)
+	write := func(path string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(tempDir, path)), os.ModePerm))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, path), []byte("hostname\n"), 0644))
+	}
+	write(filepath.Join("ams1", "core-01.cfg"))
+	index := NewIndex(tempDir)
+
+	path, ok := index.Find(layout, "core-01")
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join("ams1", "core-01.cfg"), path)
+	assert.Equal(t, filepath.Join("unknown", "core-02.cfg"), index.Locate(layout, "core-02"))
+
+	// Once built, the tree is not walked again: saves are recorded with Set.
+	write(filepath.Join("fra1", "core-02.cfg"))
+	_, ok = index.Find(layout, "core-02")
+	assert.False(t, ok)
+	index.Set("core-02", filepath.Join("fra1", "core-02.cfg"))
+	assert.Equal(t, filepath.Join("fra1", "core-02.cfg"), index.Locate(layout, "core-02"))
+
+	// Configurations removed since are not found.
+	require.NoError(t, os.Remove(filepath.Join(tempDir, "ams1", "core-01.cfg")))
+	_, ok = index.Find(layout, "core-01")
+	assert.False(t, ok)
+
+	write(filepath.Join("lon1", "core-03.cfg"))
+	index.Reset()
+	path, ok = index.Find(layout, "core-03")
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join("lon1", "core-03.cfg"), path)
+}
+
+func TestFileStoreMigrate(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "vhs-store")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	old, err := NewFileStore(tempDir, nil)
+	require.NoError(t, err)
+	first, err := old.Save(devices.NewDevice("core-01", []byte("ntp server 1.1.1.1\n")))
+	require.NoError(t, err)
+	_, err = old.Save(devices.NewDevice("core-01", []byte("ntp server 2.2.2.2\n")))
+	require.NoError(t, err)
+	_, err = old.Save(devices.NewDevice("label-01", []byte("hostname label-01\n")))
+	require.NoError(t, err)
+
+	inventory := filepath.Join(tempDir, "inventory.yaml")
+	require.NoError(t, ioutil.WriteFile(inventory, []byte("core-01:\n  site: ams1\n"), 0644))
+	layout, err := NewLayout("{site}/{type}/{hostname}", inventory)
+	require.NoError(t, err)
+	store, err := NewFileStore(tempDir, layout)
+	require.NoError(t, err)
+
+	planned, err := store.PlanMigration(nil)
+	require.NoError(t, err)
+	moves, err := store.Migrate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, planned, moves)
+	assert.Equal(t, []Move{
+		{From: filepath.Join("Core", "core-01"), To: filepath.Join("ams1", "Core", "core-01")},
+		{From: filepath.Join("Label", "label-01"), To: filepath.Join("unknown", "Label", "label-01")},
+	}, moves)
+
+	previous, err := store.Get("core-01", Query{Previous: true})
+	require.NoError(t, err)
+	assert.Equal(t, first, previous.Commit, "revisions move with the device")
+	infos, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	assert.Equal(t, filepath.Join("ams1", "Core", "core-01"), infos[0].Path)
+
+	moves, err = store.Migrate(nil)
+	require.NoError(t, err)
+	assert.Empty(t, moves, "migrating twice does nothing")
+}
+
+func TestPlanMigrationConflicts(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "vhs-store")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	for _, path := range []string{"Core/core-01", "Label/core-01"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(tempDir, filepath.Dir(path)), 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, path), nil, 0644))
+	}
+	layout, err := NewLayout("{hostname}", "")
+	require.NoError(t, err)
+	_, err = PlanMoves(tempDir, nil, layout, []DeviceInfo{{Path: filepath.Join("Core", "core-01")}, {Path: filepath.Join("Label", "core-01")}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "would both move to core-01")
+}
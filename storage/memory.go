@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"vhs/devices"
+)
+
+// MemoryStore keeps every revision in memory. It is meant for tests and local experiments.
+type MemoryStore struct {
+	mu         sync.Mutex
+	seq        int
+	revisions  map[string][]Revision
+	deprecated map[string][]Revision
+	types      map[string]string
+	metadata   map[string]devices.Metadata
+	// seen holds the time of the last backup of every device, which is later than its
+	// latest revision when the backups since did not change it.
+	seen map[string]time.Time
+}
+
+var (
+	_ Store          = (*MemoryStore)(nil)
+	_ RevisionWalker = (*MemoryStore)(nil)
+)
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		revisions:  make(map[string][]Revision),
+		deprecated: make(map[string][]Revision),
+		types:      make(map[string]string),
+		metadata:   make(map[string]devices.Metadata),
+		seen:       make(map[string]time.Time),
+	}
+}
+
+func (m *MemoryStore) Save(device devices.Device) (string, error) {
+	if err := device.Validate(); err != nil {
+		return "", err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !device.Metadata.IsZero() {
+		m.metadata[device.Name] = device.Metadata
+	}
+	for _, artifact := range device.Artifacts {
+		m.save(ArtifactName(device.Name, artifact.Name), artifact.Payload)
+	}
+	m.types[device.Name] = device.GetDeviceType()
+	m.seen[device.Name] = time.Now()
+	return m.save(device.Name, device.Payload), nil
+}
+
+// save adds payload as a revision of name, a device or ArtifactName, unless it equals the
+// latest revision.
+func (m *MemoryStore) save(name string, payload []byte) string {
+	revisions := m.revisions[name]
+	if n := len(revisions); n > 0 && bytes.Equal(revisions[n-1].Payload, payload) {
+		return revisions[n-1].Commit
+	}
+	m.seq++
+	revision := Revision{
+		Device:    name,
+		Commit:    fmt.Sprintf("%08d", m.seq),
+		Timestamp: time.Now(),
+		Payload:   append([]byte(nil), payload...),
+	}
+	m.revisions[name] = append(revisions, revision)
+	return revision.Commit
+}
+
+func (m *MemoryStore) List() ([]DeviceInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	artifacts := make(map[string][]string)
+	for name := range m.revisions {
+		if device, artifact := SplitName(name); artifact != "" {
+			artifacts[device] = append(artifacts[device], artifact)
+		}
+	}
+	var infos []DeviceInfo
+	for name, revisions := range m.revisions {
+		if _, artifact := SplitName(name); artifact != "" {
+			continue
+		}
+		sort.Strings(artifacts[name])
+		infos = append(infos, DeviceInfo{
+			Name:       name,
+			DeviceType: m.types[name],
+			LastBackup: m.lastBackup(name, revisions),
+			Metadata:   m.metadata[name],
+			Artifacts:  artifacts[name],
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+func (m *MemoryStore) Get(name string, query Query) (Revision, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	revisions := m.revisions[name]
+	index, err := selectRevision(revisions, query)
+	if err != nil {
+		return Revision{}, err
+	}
+	return revisions[index], nil
+}
+
+func (m *MemoryStore) History(name string, opts HistoryOptions) ([]HistoryEntry, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	revisions := m.revisions[name]
+	indexes, more := selectHistory(revisions, opts)
+	var entries []HistoryEntry
+	for _, i := range indexes {
+		entries = append(entries, HistoryEntry{
+			Commit:      revisions[i].Commit,
+			Timestamp:   revisions[i].Timestamp,
+			Message:     saveMessage(name),
+			PayloadSize: int64(len(revisions[i].Payload)),
+		})
+	}
+	return entries, more, nil
+}
+
+func (m *MemoryStore) Diff(name string, from string, to string) (string, error) {
+	return DiffRevisions(m, name, from, to)
+}
+
+func (m *MemoryStore) Deprecate(maxAge time.Duration) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stale := make(map[string]bool)
+	for name, revisions := range m.revisions {
+		if _, artifact := SplitName(name); artifact == "" && time.Since(m.lastBackup(name, revisions)) > maxAge {
+			stale[name] = true
+		}
+	}
+	// Artifacts are set aside with their device, however recently they changed.
+	for name, revisions := range m.revisions {
+		if device, _ := SplitName(name); stale[device] {
+			m.deprecated[name] = append(m.deprecated[name], revisions...)
+			delete(m.revisions, name)
+		}
+	}
+	return len(stale), nil
+}
+
+// lastBackup returns when the device name with revisions was last backed up.
+func (m *MemoryStore) lastBackup(name string, revisions []Revision) time.Time {
+	if seen := m.seen[name]; seen.After(revisions[len(revisions)-1].Timestamp) {
+		return seen
+	}
+	return revisions[len(revisions)-1].Timestamp
+}
+
+// WalkRevisions implements RevisionWalker, including the revisions of deprecated devices.
+func (m *MemoryStore) WalkRevisions(fn func(revision Revision) error) error {
+	m.mu.Lock()
+	var all [][]Revision
+	for _, revisions := range []map[string][]Revision{m.revisions, m.deprecated} {
+		for _, r := range revisions {
+			all = append(all, append([]Revision(nil), r...))
+		}
+	}
+	m.mu.Unlock()
+	for _, revisions := range all {
+		for i := len(revisions) - 1; i >= 0; i-- {
+			if err := fn(revisions[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Sync does nothing, a MemoryStore has no remote.
+func (m *MemoryStore) Sync() error {
+	return nil
+}
+
+// SyncStatus always reports a zero status, a MemoryStore has no remote.
+func (m *MemoryStore) SyncStatus() (SyncStatus, error) {
+	return SyncStatus{}, nil
+}
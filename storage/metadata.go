@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"vhs/devices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MetadataDir holds the metadata of the configurations in a store, as one YAML file per
+// configuration at the same path with a .yaml suffix. Device names cannot start with a dot,
+// so the folder never clashes with a configuration.
+const MetadataDir = ".metadata"
+
+// MetadataPath returns the path of the metadata of the configuration at path, relative to
+// the root of a store.
+func MetadataPath(path string) string {
+	return filepath.Join(MetadataDir, path+".yaml")
+}
+
+// ReadMetadata reads the metadata file at path below root. A missing file is zero metadata.
+func ReadMetadata(root string, path string) (devices.Metadata, error) {
+	var metadata devices.Metadata
+	content, err := ioutil.ReadFile(filepath.Join(root, path))
+	if os.IsNotExist(err) {
+		return metadata, nil
+	}
+	if err != nil {
+		return metadata, err
+	}
+	if err := yaml.Unmarshal(content, &metadata); err != nil {
+		return metadata, fmt.Errorf("failed to parse metadata %s: %w", path, err)
+	}
+	return metadata, nil
+}
+
+// WriteMetadata writes metadata to the file at path below root. Zero metadata is not
+// written, so the file keeps the metadata of an earlier backup. Neither is metadata that
+// only differs from the file in its collection time, which changes with every backup; the
+// file keeps the collection time of the backup that last changed the metadata. It reports
+// whether the file was written.
+func WriteMetadata(root string, path string, metadata devices.Metadata) (bool, error) {
+	if metadata.IsZero() {
+		return false, nil
+	}
+	// A file that cannot be read is replaced.
+	if stored, err := ReadMetadata(root, path); err == nil && !stored.IsZero() {
+		stored.CollectedAt = metadata.CollectedAt
+		if reflect.DeepEqual(stored, metadata) {
+			return false, nil
+		}
+	}
+	content, err := yaml.Marshal(metadata)
+	if err != nil {
+		return false, err
+	}
+	file := filepath.Join(root, path)
+	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		return false, err
+	}
+	return true, ioutil.WriteFile(file, content, 0644)
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"vhs/devices"
+)
+
+// Move relocates a configuration within a store. Paths are relative to the store's root.
+type Move struct {
+	From string
+	To   string
+}
+
+// Migrator is implemented by stores that can move their configurations to another layout.
+type Migrator interface {
+	// PlanMigration returns the moves Migrate would make, without making them.
+	PlanMigration(from *Layout) ([]Move, error)
+	// Migrate moves every configuration laid out by from to where the store's own layout
+	// puts it, and returns the moves it made.
+	Migrate(from *Layout) ([]Move, error)
+}
+
+// PlanMoves lists the moves taking the configurations below root, found at the paths in
+// infos and laid out by from, to the layout to. Fields the new layout needs are taken from
+// its inventory, then from the old paths.
+func PlanMoves(root string, from *Layout, to *Layout, infos []DeviceInfo) ([]Move, error) {
+	var moves []Move
+	for _, info := range infos {
+		name, fields, ok := from.Parse(info.Path)
+		if !ok {
+			continue
+		}
+		device := devices.NewDevice(name, nil)
+		if devices.ValidType(fields[typeField]) {
+			device.Type = fields[typeField]
+		}
+		device.Attributes = make(map[string]string)
+		for field, value := range fields {
+			if field != hostnameField && field != typeField && value != MissingField {
+				device.Attributes[field] = value
+			}
+		}
+		for field, value := range to.Inventory(name) {
+			device.Attributes[field] = value
+		}
+		if target := to.Path(device); target != info.Path {
+			moves = append(moves, Move{From: info.Path, To: target})
+		}
+	}
+	sort.Slice(moves, func(i, j int) bool { return moves[i].From < moves[j].From })
+
+	targets := make(map[string]string, len(moves))
+	for _, move := range moves {
+		if other, ok := targets[move.To]; ok {
+			return nil, fmt.Errorf("%s and %s would both move to %s", other, move.From, move.To)
+		}
+		targets[move.To] = move.From
+		if _, err := os.Stat(filepath.Join(root, move.To)); err == nil {
+			return nil, fmt.Errorf("cannot move %s to %s, it already exists", move.From, move.To)
+		}
+	}
+	return moves, nil
+}
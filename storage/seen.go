@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// SeenFile is the file the stores record the last backup of every device in. The git
+// backends keep it in the .git folder, so it is never committed.
+const SeenFile = "vhs-last-seen.json"
+
+// Seen records when each device was last backed up. Configurations are only rewritten
+// when they change, so the timestamp header in front of them says when they last changed;
+// deprecation needs to know when a device was last backed up at all. That time is not
+// versioned, or every backup would make a commit.
+type Seen struct {
+	path string
+
+	mu sync.Mutex
+	// times is read from path on first use. A missing or unreadable file records nothing,
+	// the timestamp headers are used instead.
+	times map[string]time.Time
+}
+
+// NewSeen records the last backups in the file at path.
+func NewSeen(path string) *Seen {
+	return &Seen{path: path}
+}
+
+func (s *Seen) load() {
+	if s.times != nil {
+		return
+	}
+	s.times = make(map[string]time.Time)
+	if content, err := ioutil.ReadFile(s.path); err == nil {
+		json.Unmarshal(content, &s.times)
+	}
+}
+
+// Record records that the named devices were backed up at at.
+func (s *Seen) Record(at time.Time, names ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	for _, name := range names {
+		s.times[name] = at
+	}
+	content, err := json.Marshal(s.times)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, content, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Last returns when the named device was last backed up, or the zero time when no backup
+// was recorded.
+func (s *Seen) Last(name string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	return s.times[name]
+}
+
+// Update sets the LastBackup of infos to the last recorded backup, when that is later than
+// the timestamp stored with the configuration.
+func (s *Seen) Update(infos []DeviceInfo) {
+	for i, info := range infos {
+		if last := s.Last(info.Name); last.After(info.LastBackup) {
+			infos[i].LastBackup = last
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Package storage defines how device configurations are persisted, versioned and read back.
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"time"
+	"vhs/devices"
+	"vhs/diff"
+)
+
+// ErrNotFound is returned when no stored configuration matches a lookup.
+var ErrNotFound = errors.New("backup not found")
+
+// Store is a versioned store of device configurations.
+type Store interface {
+	// Save stores a device's configuration and returns the ID of the revision holding it,
+	// which is the previous revision if the configuration did not change.
+	Save(device devices.Device) (string, error)
+	// List returns every device with a current configuration, sorted by name.
+	List() ([]DeviceInfo, error)
+	// Get returns the revision of a device selected by query. Get, History and Diff read a
+	// device's artifact when name is its ArtifactName.
+	Get(name string, query Query) (Revision, error)
+	// History returns the revisions of a device, newest first, and whether more exist beyond opts.Limit.
+	History(name string, opts HistoryOptions) ([]HistoryEntry, bool, error)
+	// Diff returns a unified diff of a device's configuration between two revisions.
+	Diff(name string, from string, to string) (string, error)
+	// Deprecate sets aside configurations that were not updated within maxAge and returns
+	// how many it set aside.
+	Deprecate(maxAge time.Duration) (int, error)
+	// Sync exchanges stored revisions with a remote, if the store has one. Stores that
+	// find the remote moved on integrate its changes before sending their own.
+	Sync() error
+	// SyncStatus compares the stored revisions with the remote as of the last Sync.
+	SyncStatus() (SyncStatus, error)
+}
+
+// SyncStatus counts the revisions a store and its remote do not have in common.
+// It is zero for stores without a remote.
+type SyncStatus struct {
+	// Ahead counts local revisions that were not sent to the remote yet.
+	Ahead int
+	// Behind counts remote revisions that were not integrated locally yet.
+	Behind int
+}
+
+// Query selects a revision of a device. The zero Query selects the latest revision.
+type Query struct {
+	// Revision selects a revision by ID.
+	Revision string
+	// At selects the newest revision stored at or before it.
+	At time.Time
+	// Previous selects the revision before the one selected by Revision, or before the latest one.
+	Previous bool
+}
+
+// Revision is a device configuration as stored in a single revision.
+type Revision struct {
+	Device string
+	// Commit identifies the revision; for the git store it is the commit hash.
+	Commit    string
+	Timestamp time.Time
+	Payload   []byte
+}
+
+// DeviceInfo describes a device that currently has a stored configuration.
+type DeviceInfo struct {
+	Name       string
+	DeviceType string
+	// Path is where the configuration is stored, relative to the root of the store.
+	Path       string
+	LastBackup time.Time
+	// Metadata is the metadata stored with the latest configuration.
+	Metadata devices.Metadata
+	// Artifacts names the artifacts stored with the configuration, sorted. They are read
+	// by the name ArtifactName returns.
+	Artifacts []string
+}
+
+// HistoryEntry describes one revision of a device's configuration.
+type HistoryEntry struct {
+	Commit      string
+	Timestamp   time.Time
+	Message     string
+	Author      string
+	PayloadSize int64
+}
+
+// HistoryOptions narrows and pages the result of History.
+// Zero values leave the corresponding bound open.
+type HistoryOptions struct {
+	Since time.Time
+	Until time.Time
+	Skip  int
+	Limit int
+}
+
+// SplitHeader separates the RFC3339 timestamp line stored in front of every configuration
+// from the payload. Content without a valid header is returned unchanged.
+func SplitHeader(content []byte) (time.Time, []byte) {
+	line, rest, found := bytes.Cut(content, []byte("\n"))
+	timestamp, err := time.Parse(time.RFC3339, string(line))
+	if err != nil {
+		return time.Time{}, content
+	}
+	if !found {
+		return timestamp, nil
+	}
+	return timestamp, rest
+}
+
+// Diff returns a unified diff between two revisions of a device.
+func Diff(from Revision, to Revision) string {
+	return diff.Unified(revisionLabel(from), revisionLabel(to), from.Payload, to.Payload, 3)
+}
+
+func revisionLabel(revision Revision) string {
+	commit := revision.Commit
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	return revision.Device + "@" + commit
+}
+
+// DiffRevisions loads two revisions of a device from store and diffs them.
+func DiffRevisions(store Store, name string, from string, to string) (string, error) {
+	fromRevision, err := store.Get(name, Query{Revision: from})
+	if err != nil {
+		return "", err
+	}
+	toRevision, err := store.Get(name, Query{Revision: to})
+	if err != nil {
+		return "", err
+	}
+	return Diff(fromRevision, toRevision), nil
+}
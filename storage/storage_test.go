@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+	"vhs/devices"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "vhs-store")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	store, err := NewFileStore(tempDir, nil)
+	require.NoError(t, err)
+	testStore(t, store)
+}
+
+// testStore checks the behaviour every Store implementation shares.
+func testStore(t *testing.T, store Store) {
+	_, err := store.Get("core-01", Query{})
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = store.Save(devices.NewDevice("../core-01", []byte("ntp server 1.1.1.1\n")))
+	assert.ErrorIs(t, err, devices.ErrInvalidName)
+
+	first, err := store.Save(devices.NewDevice("core-01", []byte("ntp server 1.1.1.1\n")))
+	require.NoError(t, err)
+	unchanged, err := store.Save(devices.NewDevice("core-01", []byte("ntp server 1.1.1.1\n")))
+	require.NoError(t, err)
+	assert.Equal(t, first, unchanged, "saving the same configuration does not create a revision")
+	beforeSecond := time.Now()
+	second, err := store.Save(devices.NewDevice("core-01", []byte("ntp server 2.2.2.2\n")))
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+	_, err = store.Save(devices.NewDevice("label-01", []byte("hostname label-01\n")))
+	require.NoError(t, err)
+
+	latest, err := store.Get("core-01", Query{})
+	require.NoError(t, err)
+	assert.Equal(t, second, latest.Commit)
+	assert.Equal(t, "ntp server 2.2.2.2\n", string(latest.Payload))
+
+	previous, err := store.Get("core-01", Query{Previous: true})
+	require.NoError(t, err)
+	assert.Equal(t, first, previous.Commit)
+	_, err = store.Get("core-01", Query{Revision: first, Previous: true})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	atFirst, err := store.Get("core-01", Query{At: beforeSecond})
+	require.NoError(t, err)
+	assert.Equal(t, first, atFirst.Commit)
+
+	byID, err := store.Get("core-01", Query{Revision: first})
+	require.NoError(t, err)
+	assert.Equal(t, "ntp server 1.1.1.1\n", string(byID.Payload))
+
+	entries, more, err := store.History("core-01", HistoryOptions{Limit: 1})
+	require.NoError(t, err)
+	assert.True(t, more)
+	require.Len(t, entries, 1)
+	assert.Equal(t, second, entries[0].Commit)
+	assert.Equal(t, int64(len("ntp server 2.2.2.2\n")), entries[0].PayloadSize)
+	entries, more, err = store.History("core-01", HistoryOptions{Skip: 1, Limit: 1})
+	require.NoError(t, err)
+	assert.False(t, more)
+	require.Len(t, entries, 1)
+	assert.Equal(t, first, entries[0].Commit)
+
+	diff, err := store.Diff("core-01", first, second)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "-ntp server 1.1.1.1\n+ntp server 2.2.2.2\n")
+
+	infos, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	assert.Equal(t, "core-01", infos[0].Name)
+	assert.Equal(t, "Core", infos[0].DeviceType)
+	assert.Equal(t, "label-01", infos[1].Name)
+
+	assert.True(t, infos[1].Metadata.IsZero())
+
+	// Artifacts are versioned on their own, next to the configuration.
+	withArtifacts := devices.NewDevice("label-01", []byte("hostname label-01\n"))
+	withArtifacts.Artifacts = []devices.Artifact{
+		{Name: "show_version", Payload: []byte("version 1\n")},
+		{Name: "show_interfaces", Payload: []byte("et1 up\n")},
+	}
+	_, err = store.Save(withArtifacts)
+	require.NoError(t, err)
+	withArtifacts.Artifacts[1].Payload = []byte("et1 down\n")
+	_, err = store.Save(withArtifacts)
+	require.NoError(t, err)
+	version, err := store.Get(ArtifactName("label-01", "show_version"), Query{})
+	require.NoError(t, err)
+	assert.Equal(t, "version 1\n", string(version.Payload))
+	entries, _, err = store.History(ArtifactName("label-01", "show_version"), HistoryOptions{})
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+	entries, _, err = store.History(ArtifactName("label-01", "show_interfaces"), HistoryOptions{})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	diff, err = store.Diff(ArtifactName("label-01", "show_interfaces"), entries[1].Commit, entries[0].Commit)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "-et1 up\n+et1 down\n")
+	entries, _, err = store.History("label-01", HistoryOptions{})
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "unchanged configurations get no revision when only artifacts change")
+	_, err = store.Save(devices.Device{Name: "label-01", Artifacts: []devices.Artifact{{Name: "../x"}}})
+	assert.Error(t, err)
+
+	// A device classified differently keeps its history under the new type.
+	reclassified := devices.NewDevice("label-01", []byte("hostname label-01\nvlan 10\n"))
+	reclassified.Type = "Access"
+	reclassified.Metadata = devices.Metadata{Vendor: "juniper", Tags: []string{"lab"}}
+	_, err = store.Save(reclassified)
+	require.NoError(t, err)
+	infos, err = store.List()
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	assert.Equal(t, "Access", infos[1].DeviceType)
+	assert.Equal(t, reclassified.Metadata, infos[1].Metadata)
+	assert.Equal(t, []string{"show_interfaces", "show_version"}, infos[1].Artifacts)
+	_, err = store.Get(ArtifactName("label-01", "show_version"), Query{})
+	assert.NoError(t, err, "artifacts move with the configuration")
+	entries, _, err = store.History("label-01", HistoryOptions{})
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	deprecated, err := store.Deprecate(time.Hour)
+	require.NoError(t, err)
+	assert.Zero(t, deprecated)
+	infos, err = store.List()
+	require.NoError(t, err)
+	assert.Len(t, infos, 2)
+	time.Sleep(10 * time.Millisecond)
+	deprecated, err = store.Deprecate(time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, 2, deprecated)
+	infos, err = store.List()
+	require.NoError(t, err)
+	assert.Empty(t, infos)
+
+	// Deprecation goes by the last backup, not the last change, and a device that came
+	// back can be deprecated again.
+	returned := reclassified
+	returned.Artifacts = withArtifacts.Artifacts
+	_, err = store.Save(returned)
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	_, err = store.Save(returned)
+	require.NoError(t, err)
+	deprecated, err = store.Deprecate(50 * time.Millisecond)
+	require.NoError(t, err)
+	assert.Zero(t, deprecated, "an unchanged backup keeps the device")
+	time.Sleep(100 * time.Millisecond)
+	deprecated, err = store.Deprecate(50 * time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deprecated)
+	infos, err = store.List()
+	require.NoError(t, err)
+	assert.Empty(t, infos)
+
+	assert.NoError(t, store.Sync())
+	status, err := store.SyncStatus()
+	require.NoError(t, err)
+	assert.Equal(t, SyncStatus{}, status)
+}
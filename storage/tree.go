@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+	"vhs/devices"
+)
+
+// DeprecatedDir is the folder configurations are moved to once they are deprecated.
+const DeprecatedDir = "deprecated"
+
+// Path returns the path a device's configuration is saved to, relative to the root of a
+// store.
+func (l *Layout) Path(device devices.Device) string {
+	return filepath.FromSlash(l.get().render(device))
+}
+
+// List lists the configurations in a working tree laid out by l, sorted by name, along with
+// their metadata and artifacts. The deprecated, .git, metadata and artifacts folders are skipped, as are files the
+// layout does not describe.
+func (l *Layout) List(root string) ([]DeviceInfo, error) {
+	var infos []DeviceInfo
+	err := l.walk(root, func(path string, name string, fields map[string]string, info os.FileInfo) error {
+		if info.IsDir() {
+			return nil
+		}
+		file, err := os.Open(filepath.Join(root, path))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		var timestamp time.Time
+		scanner := bufio.NewScanner(file)
+		if scanner.Scan() {
+			timestamp, _ = time.Parse(time.RFC3339, scanner.Text())
+		}
+		metadata, err := ReadMetadata(root, MetadataPath(path))
+		if err != nil {
+			return err
+		}
+		artifacts, err := ListArtifacts(root, ArtifactsPath(path))
+		if err != nil {
+			return err
+		}
+		infos = append(infos, DeviceInfo{
+			Name:       name,
+			DeviceType: fields[typeField],
+			Path:       path,
+			LastBackup: timestamp,
+			Metadata:   metadata,
+			Artifacts:  artifacts,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+// walk calls fn for every file and directory below root whose path the layout describes.
+// Matching directories are not descended into. The deprecated, .git, metadata and artifacts
+// folders are skipped.
+func (l *Layout) walk(root string, fn func(path string, name string, fields map[string]string, info os.FileInfo) error) error {
+	deprecatedFolderPath := filepath.Join(root, DeprecatedDir)
+	gitFolderPath := filepath.Join(root, ".git")
+	metadataFolderPath := filepath.Join(root, MetadataDir)
+	artifactsFolderPath := filepath.Join(root, ArtifactsDir)
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == deprecatedFolderPath || path == gitFolderPath || path == metadataFolderPath || path == artifactsFolderPath {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		name, fields, ok := l.Parse(rel)
+		if !ok {
+			return nil
+		}
+		if err := fn(rel, name, fields, info); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"vhs/devices"
+)
+
+// selectRevision returns the index of the revision matching query among revisions ordered
+// oldest first. Revisions only need their Commit and Timestamp set.
+func selectRevision(revisions []Revision, query Query) (int, error) {
+	if len(revisions) == 0 {
+		return -1, ErrNotFound
+	}
+	index := len(revisions) - 1
+	switch {
+	case query.Revision != "":
+		index = -1
+		for i, revision := range revisions {
+			if revision.Commit == query.Revision {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return -1, fmt.Errorf("%w: unknown revision %s", ErrNotFound, query.Revision)
+		}
+	case !query.At.IsZero():
+		index = sort.Search(len(revisions), func(i int) bool {
+			return revisions[i].Timestamp.After(query.At)
+		}) - 1
+		if index < 0 {
+			return -1, ErrNotFound
+		}
+	}
+	if query.Previous {
+		index--
+		if index < 0 {
+			return -1, fmt.Errorf("%w: no revision before %s", ErrNotFound, revisions[0].Commit)
+		}
+	}
+	return index, nil
+}
+
+// selectHistory returns the indexes of the revisions matching opts among revisions ordered
+// oldest first, newest first, and whether more exist beyond opts.Limit.
+func selectHistory(revisions []Revision, opts HistoryOptions) ([]int, bool) {
+	var matched []int
+	for i := len(revisions) - 1; i >= 0; i-- {
+		timestamp := revisions[i].Timestamp
+		if !opts.Since.IsZero() && timestamp.Before(opts.Since) {
+			continue
+		}
+		if !opts.Until.IsZero() && timestamp.After(opts.Until) {
+			continue
+		}
+		matched = append(matched, i)
+	}
+	if opts.Skip >= len(matched) {
+		return nil, false
+	}
+	matched = matched[opts.Skip:]
+	if opts.Limit > 0 && len(matched) > opts.Limit {
+		return matched[:opts.Limit], true
+	}
+	return matched, false
+}
+
+func saveMessage(name string) string {
+	return fmt.Sprintf("Updated configuration for device %s", name)
+}
+
+// CommitMessage builds the message of a commit saving devs. Every device gets a "Device:"
+// trailer, followed by a "Collector:" trailer for every collector that took one of their
+// backups, so that git log tells where a change came from.
+func CommitMessage(devs []devices.Device) string {
+	var b strings.Builder
+	if len(devs) == 1 {
+		fmt.Fprintf(&b, "%s\n\n", saveMessage(devs[0].Name))
+	} else {
+		fmt.Fprintf(&b, "Updated configuration for %d devices\n\n", len(devs))
+	}
+	seen := make(map[string]bool)
+	var collectors []string
+	for _, device := range devs {
+		fmt.Fprintf(&b, "Device: %s\n", device.Name)
+		if collector := device.Metadata.Collector; collector != "" && !seen[collector] {
+			seen[collector] = true
+			collectors = append(collectors, collector)
+		}
+	}
+	for _, collector := range collectors {
+		fmt.Fprintf(&b, "Collector: %s\n", collector)
+	}
+	return b.String()
+}
@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// RevisionWalker is implemented by stores that can go through every revision they hold,
+// including those of devices they no longer list because they were deprecated or removed.
+type RevisionWalker interface {
+	// WalkRevisions calls fn with every stored revision of every configuration and
+	// artifact, newest first for each of them. Revision.Device is the name Get reads the
+	// revision by: the device name, or the ArtifactName of an artifact.
+	WalkRevisions(fn func(revision Revision) error) error
+}
+
+// NameAt returns the name Get reads the file at path by, relative to the root of a working
+// tree laid out by l, also when the file was deprecated. Metadata files and files the
+// layout does not describe have none.
+func (l *Layout) NameAt(path string) (string, bool) {
+	path = strings.TrimPrefix(filepath.ToSlash(path), DeprecatedDir+"/")
+	if strings.HasPrefix(path, MetadataDir+"/") {
+		return "", false
+	}
+	if rest, ok := strings.CutPrefix(path, ArtifactsDir+"/"); ok {
+		i := strings.LastIndex(rest, "/")
+		if i < 0 {
+			return "", false
+		}
+		device, _, ok := l.Parse(rest[:i])
+		return ArtifactName(device, rest[i+1:]), ok
+	}
+	name, _, ok := l.Parse(path)
+	return name, ok
+}